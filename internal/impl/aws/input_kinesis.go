@@ -105,6 +105,12 @@ Redpanda Connect will not store a consumed sequence unless it is acknowledged at
 
 By default messages of a shard can be processed in parallel, up to a limit determined by the field `+"`checkpoint_limit`"+`. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step. When doing so it is recommended that you perform batching at this component for performance as it will not be possible to batch lock-stepped messages at the output level.
 
+== Resharding
+
+When shards are balanced automatically the child shards created by splitting or merging shards are only consumed once their parent shards have been fully consumed, which preserves the ordering of records that share a partition key across a resharding. Closed shards that are still retained by the stream are consumed when they have no checkpoint, and once a shard is fully consumed its checkpoint is replaced with one marking it as finished, which is removed once the shard is no longer listed by the stream.
+
+The checkpoints of this input are not compatible with the lease tables of the Kinesis Client Library, and enhanced fan-out consumers are not supported.
+
 == Table schema
 
 It's possible to configure Redpanda Connect to create the DynamoDB table required for coordination if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key `+"`StreamID`"+` and a string RANGE key `+"`ShardID`"+`.
@@ -489,8 +495,8 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, info streamInfo, shardID
 			switch state {
 			case awsKinesisConsumerFinished:
 				reason = " because the shard is closed"
-				if err := k.checkpointer.Delete(k.ctx, info.id, shardID); err != nil {
-					k.log.Errorf("Failed to remove checkpoint for finished stream '%v' shard '%v': %v", info.id, shardID, err)
+				if err := k.checkpointer.Finish(k.ctx, info.id, shardID); err != nil {
+					k.log.Errorf("Failed to store finished checkpoint for stream '%v' shard '%v': %v", info.id, shardID, err)
 				}
 			case awsKinesisConsumerYielding:
 				reason = " because the shard has been claimed by another client"
@@ -644,6 +650,100 @@ func isShardFinished(s types.Shard) bool {
 	return *s.SequenceNumberRange.EndingSequenceNumber != "null"
 }
 
+// shardsToClaim returns a map of shards that are available to be claimed to
+// the client ID that they should be claimed from, which is empty for shards
+// that are not currently claimed.
+//
+// In order to preserve the ordering of records across a resharding the child
+// shards of a split or merge are not made available until their parents are
+// fully consumed. A shard is fully consumed once it has a finished checkpoint,
+// or when it is closed without a checkpoint and one of its descendants has a
+// checkpoint, as older versions removed the checkpoints of finished shards.
+// Closed shards without a checkpoint are otherwise yet to be consumed.
+func shardsToClaim(shards []types.Shard, clientClaims map[string][]awsKinesisClientClaim, leasePeriod time.Duration) map[string]string {
+	type shardClaim struct {
+		clientID string
+		claim    awsKinesisClientClaim
+	}
+	claims := map[string]shardClaim{}
+	for clientID, cc := range clientClaims {
+		for _, claim := range cc {
+			claims[claim.ShardID] = shardClaim{clientID: clientID, claim: claim}
+		}
+	}
+
+	listed := make(map[string]types.Shard, len(shards))
+	children := map[string][]string{}
+	for _, s := range shards {
+		listed[*s.ShardId] = s
+		for _, parentID := range []*string{s.ParentShardId, s.AdjacentParentShardId} {
+			if parentID != nil {
+				children[*parentID] = append(children[*parentID], *s.ShardId)
+			}
+		}
+	}
+
+	var hasCheckpointedDescendant func(id string) bool
+	hasCheckpointedDescendant = func(id string) bool {
+		for _, childID := range children[id] {
+			if _, exists := claims[childID]; exists || hasCheckpointedDescendant(childID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	isConsumed := func(s types.Shard) bool {
+		if c, exists := claims[*s.ShardId]; exists {
+			return c.claim.Finished
+		}
+		return isShardFinished(s) && hasCheckpointedDescendant(*s.ShardId)
+	}
+
+	hasPendingParent := func(s types.Shard) bool {
+		for _, parentID := range []*string{s.ParentShardId, s.AdjacentParentShardId} {
+			if parentID == nil {
+				continue
+			}
+			if parent, isListed := listed[*parentID]; isListed && !isConsumed(parent) {
+				return true
+			}
+		}
+		return false
+	}
+
+	unclaimedShards := make(map[string]string, len(shards))
+	for _, s := range shards {
+		if isConsumed(s) || hasPendingParent(s) {
+			continue
+		}
+		c, exists := claims[*s.ShardId]
+		switch {
+		case !exists, c.clientID == "":
+			unclaimedShards[*s.ShardId] = ""
+		case time.Since(c.claim.LeaseTimeout) > leasePeriod*2:
+			unclaimedShards[*s.ShardId] = c.clientID
+		}
+	}
+	return unclaimedShards
+}
+
+// unlistedFinishedShards returns the shards with a finished checkpoint that are
+// no longer listed by the stream, and whose checkpoints can be removed.
+func unlistedFinishedShards(shards []types.Shard, clientClaims map[string][]awsKinesisClientClaim) []string {
+	listed := make(map[string]struct{}, len(shards))
+	for _, s := range shards {
+		listed[*s.ShardId] = struct{}{}
+	}
+	var finished []string
+	for _, claim := range clientClaims[""] {
+		if _, isListed := listed[claim.ShardID]; claim.Finished && !isListed {
+			finished = append(finished, claim.ShardID)
+		}
+	}
+	return finished
+}
+
 func (k *kinesisReader) runBalancedShards() {
 	var wg sync.WaitGroup
 	defer func() {
@@ -672,23 +772,14 @@ func (k *kinesisReader) runBalancedShards() {
 				continue
 			}
 
-			totalShards := len(shardsRes.Shards)
-			unclaimedShards := make(map[string]string, totalShards)
-			for _, s := range shardsRes.Shards {
-				if !isShardFinished(s) {
-					unclaimedShards[*s.ShardId] = ""
-				}
-			}
-			for clientID, claims := range clientClaims {
-				for _, claim := range claims {
-					if time.Since(claim.LeaseTimeout) > k.leasePeriod*2 {
-						unclaimedShards[claim.ShardID] = clientID
-					} else {
-						delete(unclaimedShards, claim.ShardID)
-					}
+			for _, shardID := range unlistedFinishedShards(shardsRes.Shards, clientClaims) {
+				if err := k.checkpointer.Delete(k.ctx, info.id, shardID); err != nil {
+					k.log.Errorf("Failed to remove checkpoint for finished stream '%v' shard '%v': %v", info.id, shardID, err)
 				}
 			}
 
+			unclaimedShards := shardsToClaim(shardsRes.Shards, clientClaims, k.leasePeriod)
+
 			// Have a go at grabbing any unclaimed shards
 			if len(unclaimedShards) > 0 {
 				for shardID, clientID := range unclaimedShards {
@@ -716,7 +807,7 @@ func (k *kinesisReader) runBalancedShards() {
 			// There were no unclaimed shards, let's look for a shard to steal.
 			selfClaims := len(clientClaims[k.clientID])
 			for clientID, claims := range clientClaims {
-				if clientID == k.clientID {
+				if clientID == k.clientID || clientID == "" {
 					// Don't steal from ourself, we're not at that point yet.
 					continue
 				}
@@ -791,6 +882,13 @@ func (k *kinesisReader) runExplicitShards() {
 			var failedShards []string
 			for _, shardID := range info.explicitShards {
 				sequence, err := k.checkpointer.Claim(k.ctx, id, shardID, "")
+				if err == nil && sequence == awsKinesisShardEnd {
+					k.log.Debugf("Skipping finished stream '%v' shard '%v'", id, shardID)
+					err = k.checkpointer.Finish(k.ctx, id, shardID)
+					if err == nil {
+						continue
+					}
+				}
 				if err == nil {
 					wg.Add(1)
 					err = k.runConsumer(&wg, info, shardID, sequence)
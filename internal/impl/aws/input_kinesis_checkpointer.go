@@ -188,14 +188,21 @@ func (k *awsKinesisCheckpointer) getCheckpoint(ctx context.Context, streamID, sh
 
 //------------------------------------------------------------------------------
 
+// awsKinesisShardEnd is the sequence number of the checkpoint of a shard that
+// has been fully consumed.
+const awsKinesisShardEnd = "SHARD_END"
+
 // awsKinesisClientClaim represents a shard claimed by a client.
 type awsKinesisClientClaim struct {
 	ShardID      string
 	LeaseTimeout time.Time
+	Finished     bool
 }
 
 // AllClaims returns a map of client IDs to shards claimed by that client,
-// including the lease timeout of the claim.
+// including the lease timeout of the claim. Shards that have a checkpoint but
+// are not claimed by any client are returned under an empty client ID, and
+// have a zero lease timeout.
 func (k *awsKinesisCheckpointer) AllClaims(ctx context.Context, streamID string) (map[string][]awsKinesisClientClaim, error) {
 	clientClaims := make(map[string][]awsKinesisClientClaim)
 	var scanErr error
@@ -217,8 +224,6 @@ func (k *awsKinesisCheckpointer) AllClaims(ctx context.Context, streamID string)
 		var clientID string
 		if s, ok := i["ClientID"].(*types.AttributeValueMemberS); ok {
 			clientID = s.Value
-		} else {
-			continue
 		}
 
 		var claim awsKinesisClientClaim
@@ -229,12 +234,16 @@ func (k *awsKinesisCheckpointer) AllClaims(ctx context.Context, streamID string)
 			return nil, errors.New("failed to extract shard id from claim")
 		}
 
+		if s, ok := i["SequenceNumber"].(*types.AttributeValueMemberS); ok {
+			claim.Finished = s.Value == awsKinesisShardEnd
+		}
+
 		if s, ok := i["LeaseTimeout"].(*types.AttributeValueMemberS); ok {
 			if claim.LeaseTimeout, scanErr = time.Parse(time.RFC3339Nano, s.Value); scanErr != nil {
 				return nil, fmt.Errorf("failed to parse claim lease: %w", scanErr)
 			}
 		}
-		if claim.LeaseTimeout.IsZero() {
+		if clientID != "" && claim.LeaseTimeout.IsZero() {
 			return nil, errors.New("failed to extract lease timeout from claim")
 		}
 
@@ -420,8 +429,29 @@ func (k *awsKinesisCheckpointer) Yield(ctx context.Context, streamID, shardID, s
 	return err
 }
 
-// Delete attempts to delete a checkpoint, this should be called when a shard is
-// emptied.
+// Finish replaces the checkpoint of a shard with one that marks the shard as
+// fully consumed and unclaimed, this should be called when a shard is emptied
+// so that its child shards can be consumed.
+func (k *awsKinesisCheckpointer) Finish(ctx context.Context, streamID, shardID string) error {
+	_, err := k.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(k.conf.Table),
+		Item: map[string]types.AttributeValue{
+			"StreamID": &types.AttributeValueMemberS{
+				Value: streamID,
+			},
+			"ShardID": &types.AttributeValueMemberS{
+				Value: shardID,
+			},
+			"SequenceNumber": &types.AttributeValueMemberS{
+				Value: awsKinesisShardEnd,
+			},
+		},
+	})
+	return err
+}
+
+// Delete attempts to delete a checkpoint, this should be called once a finished
+// shard is no longer listed by its stream.
 func (k *awsKinesisCheckpointer) Delete(ctx context.Context, streamID, shardID string) error {
 	_, err := k.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: aws.String(k.conf.Table),
@@ -16,7 +16,10 @@ package aws
 
 import (
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,3 +81,128 @@ func TestStreamIDParser(t *testing.T) {
 		})
 	}
 }
+
+func TestKinesisShardsToClaim(t *testing.T) {
+	openShard := func(id string, parents ...string) types.Shard {
+		s := types.Shard{
+			ShardId: aws.String(id),
+			SequenceNumberRange: &types.SequenceNumberRange{
+				StartingSequenceNumber: aws.String("1"),
+			},
+		}
+		if len(parents) > 0 {
+			s.ParentShardId = aws.String(parents[0])
+		}
+		if len(parents) > 1 {
+			s.AdjacentParentShardId = aws.String(parents[1])
+		}
+		return s
+	}
+	closedShard := func(id string, parents ...string) types.Shard {
+		s := openShard(id, parents...)
+		s.SequenceNumberRange.EndingSequenceNumber = aws.String("10")
+		return s
+	}
+
+	active := time.Now()
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		shards   []types.Shard
+		claims   map[string][]awsKinesisClientClaim
+		expected map[string]string
+	}{
+		{
+			name:   "no claims",
+			shards: []types.Shard{openShard("a"), openShard("b")},
+			expected: map[string]string{
+				"a": "",
+				"b": "",
+			},
+		},
+		{
+			name:   "active and expired claims",
+			shards: []types.Shard{openShard("a"), openShard("b"), openShard("c")},
+			claims: map[string][]awsKinesisClientClaim{
+				"foo": {{ShardID: "a", LeaseTimeout: active}},
+				"bar": {{ShardID: "b", LeaseTimeout: expired}},
+			},
+			expected: map[string]string{
+				"b": "bar",
+				"c": "",
+			},
+		},
+		{
+			name:   "split with parent pending",
+			shards: []types.Shard{closedShard("a"), openShard("b", "a"), openShard("c", "a")},
+			claims: map[string][]awsKinesisClientClaim{
+				"": {{ShardID: "a"}},
+			},
+			expected: map[string]string{
+				"a": "",
+			},
+		},
+		{
+			name:   "split with parent not yet consumed",
+			shards: []types.Shard{closedShard("a"), openShard("b", "a"), openShard("c", "a")},
+			expected: map[string]string{
+				"a": "",
+			},
+		},
+		{
+			name:   "split with parent finished",
+			shards: []types.Shard{closedShard("a"), openShard("b", "a"), openShard("c", "a")},
+			claims: map[string][]awsKinesisClientClaim{
+				"": {{ShardID: "a", Finished: true}},
+			},
+			expected: map[string]string{
+				"b": "",
+				"c": "",
+			},
+		},
+		{
+			name:   "split with parent checkpoint removed",
+			shards: []types.Shard{closedShard("a"), closedShard("b", "a"), openShard("c", "b")},
+			claims: map[string][]awsKinesisClientClaim{
+				"foo": {{ShardID: "c", LeaseTimeout: active}},
+			},
+			expected: map[string]string{},
+		},
+		{
+			name:   "merge with adjacent parent pending",
+			shards: []types.Shard{closedShard("a"), closedShard("b"), openShard("c", "a", "b")},
+			claims: map[string][]awsKinesisClientClaim{
+				"foo": {{ShardID: "b", LeaseTimeout: active}},
+			},
+			expected: map[string]string{
+				"a": "",
+			},
+		},
+		{
+			name:   "parent no longer listed",
+			shards: []types.Shard{openShard("b", "a")},
+			claims: map[string][]awsKinesisClientClaim{
+				"": {{ShardID: "a"}},
+			},
+			expected: map[string]string{
+				"b": "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, shardsToClaim(test.shards, test.claims, time.Second*30))
+		})
+	}
+}
+
+func TestKinesisUnlistedFinishedShards(t *testing.T) {
+	shards := []types.Shard{{ShardId: aws.String("b")}}
+	claims := map[string][]awsKinesisClientClaim{
+		"":    {{ShardID: "a", Finished: true}, {ShardID: "b", Finished: true}, {ShardID: "c"}},
+		"foo": {{ShardID: "d", LeaseTimeout: time.Now()}},
+	}
+	assert.Equal(t, []string{"a"}, unlistedFinishedShards(shards, claims))
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/cenkalti/backoff/v4"
@@ -39,16 +40,21 @@ const (
 	sqsiFieldDeleteMessage       = "delete_message"
 	sqsiFieldResetVisibility     = "reset_visibility"
 	sqsiFieldMaxNumberOfMessages = "max_number_of_messages"
+	sqsiFieldExtendedPayload     = "extended_payload"
+	sqsiFieldEPEnabled           = "enabled"
+	sqsiFieldEPDeleteObjects     = "delete_objects"
 
 	sqsiAttributeNameVisibilityTimeout = "VisibilityTimeout"
 )
 
 type sqsiConfig struct {
-	URL                 string
-	WaitTimeSeconds     int
-	DeleteMessage       bool
-	ResetVisibility     bool
-	MaxNumberOfMessages int
+	URL                    string
+	WaitTimeSeconds        int
+	DeleteMessage          bool
+	ResetVisibility        bool
+	MaxNumberOfMessages    int
+	ExtendedPayload        bool
+	DeleteExtendedPayloads bool
 }
 
 func sqsiConfigFromParsed(pConf *service.ParsedConfig) (conf sqsiConfig, err error) {
@@ -67,6 +73,12 @@ func sqsiConfigFromParsed(pConf *service.ParsedConfig) (conf sqsiConfig, err err
 	if conf.MaxNumberOfMessages, err = pConf.FieldInt(sqsiFieldMaxNumberOfMessages); err != nil {
 		return
 	}
+	if conf.ExtendedPayload, err = pConf.FieldBool(sqsiFieldExtendedPayload, sqsiFieldEPEnabled); err != nil {
+		return
+	}
+	if conf.DeleteExtendedPayloads, err = pConf.FieldBool(sqsiFieldExtendedPayload, sqsiFieldEPDeleteObjects); err != nil {
+		return
+	}
 	return
 }
 
@@ -90,10 +102,17 @@ This input adds the following metadata fields to each message:
 - sqs_message_id
 - sqs_receipt_handle
 - sqs_approximate_receive_count
+- sqs_message_group_id (FIFO queues only)
+- sqs_message_deduplication_id (FIFO queues only)
+- sqs_sequence_number (FIFO queues only)
 - All message attributes
 
 You can access these metadata fields using
-xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].`).
+xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Extended payloads
+
+Messages that are larger than the SQS size limit can be sent by offloading their payloads to S3 and sending a pointer to the object instead, following the format of the AWS extended client libraries as well as the `+"`aws_sqs`"+` output. When `+"`"+sqsiFieldExtendedPayload+"."+sqsiFieldEPEnabled+"`"+` is set, pointer messages are identified by their size attribute and replaced with the contents of the object they point to. Messages with a size attribute but a malformed pointer are logged and passed through unchanged.`).
 		Fields(
 			service.NewURLField(sqsiFieldURL).
 				Description("The SQS URL to consume from."),
@@ -114,6 +133,17 @@ xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].`
 				Description("Whether to set the wait time. Enabling this activates long-polling. Valid values: 0 to 20.").
 				Default(0).
 				Advanced(),
+			service.NewObjectField(sqsiFieldExtendedPayload,
+				service.NewBoolField(sqsiFieldEPEnabled).
+					Description("Whether to replace pointer messages with the payloads they point to in S3.").
+					Default(false),
+				service.NewBoolField(sqsiFieldEPDeleteObjects).
+					Description("Whether to delete the S3 object of a payload once its message is acked and deleted.").
+					Default(false),
+			).
+				Description("Options for consuming messages with payloads that were offloaded to S3.").
+				Version("4.40.0").
+				Advanced(),
 		).
 		Fields(config.SessionFields()...)
 }
@@ -152,6 +182,7 @@ type awsSQSReader struct {
 
 	aconf aws.Config
 	sqs   sqsAPI
+	s3    sqsExtendedPayloadS3API
 
 	messagesChan     chan types.Message
 	ackMessagesChan  chan sqsMessageHandle
@@ -179,6 +210,9 @@ func (a *awsSQSReader) Connect(ctx context.Context) error {
 	if a.sqs == nil {
 		a.sqs = sqs.NewFromConfig(a.aconf)
 	}
+	if a.conf.ExtendedPayload && a.s3 == nil {
+		a.s3 = s3.NewFromConfig(a.aconf)
+	}
 
 	ift := &sqsInFlightTracker{
 		handles: map[string]sqsInFlightHandle{},
@@ -491,6 +525,15 @@ func addSQSMetadata(p *service.Message, sqsMsg types.Message) {
 	if rCountStr, exists := sqsMsg.Attributes["ApproximateReceiveCount"]; exists {
 		p.MetaSetMut("sqs_approximate_receive_count", rCountStr)
 	}
+	if groupID, exists := sqsMsg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; exists {
+		p.MetaSetMut("sqs_message_group_id", groupID)
+	}
+	if dedupeID, exists := sqsMsg.Attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)]; exists {
+		p.MetaSetMut("sqs_message_deduplication_id", dedupeID)
+	}
+	if seq, exists := sqsMsg.Attributes[string(types.MessageSystemAttributeNameSequenceNumber)]; exists {
+		p.MetaSetMut("sqs_sequence_number", seq)
+	}
 	for k, v := range sqsMsg.MessageAttributes {
 		if v.StringValue != nil {
 			p.MetaSetMut(k, *v.StringValue)
@@ -521,15 +564,48 @@ func (a *awsSQSReader) Read(ctx context.Context) (*service.Message, service.AckF
 		return nil, nil, context.Canceled
 	}
 
-	msg := service.NewMessage([]byte(*next.Body))
-	addSQSMetadata(msg, next)
-
 	mHandle := sqsMessageHandle{
 		id: *next.MessageId,
 	}
 	if next.ReceiptHandle != nil {
 		mHandle.receiptHandle = *next.ReceiptHandle
 	}
+
+	body := []byte(*next.Body)
+
+	var pointer *sqsExtendedPayloadPointer
+	if a.conf.ExtendedPayload {
+		p, isPointer, err := sqsExtendedPayloadPointerFromMessage(next)
+		if err != nil {
+			// A malformed pointer would fail on every redelivery, and so the
+			// message is passed through as it is.
+			a.log.Warnf("Passing through message '%v' unchanged: %v", mHandle.id, err)
+		} else if isPointer {
+			pointer = &p
+			if body, err = p.get(ctx, a.s3); err != nil {
+				// Hand the message back to the ack loop so that it is no
+				// longer kept in flight and can be redelivered.
+				if mHandle.receiptHandle != "" {
+					select {
+					case a.nackMessagesChan <- mHandle:
+					case <-a.closeSignal.SoftStopChan():
+					case <-ctx.Done():
+					}
+				}
+				return nil, nil, err
+			}
+		}
+	}
+
+	msg := service.NewMessage(body)
+	addSQSMetadata(msg, next)
+	if pointer != nil {
+		// The size attributes mark a message as a pointer, and so they must not
+		// be forwarded along with the resolved payload.
+		msg.MetaDelete(sqsExtendedPayloadSizeAttribute)
+		msg.MetaDelete(sqsLegacyExtendedPayloadSizeAttribute)
+	}
+
 	return msg, func(rctx context.Context, res error) error {
 		if mHandle.receiptHandle == "" {
 			return nil
@@ -539,6 +615,11 @@ func (a *awsSQSReader) Read(ctx context.Context) (*service.Message, service.AckF
 			if !a.conf.DeleteMessage {
 				return nil
 			}
+			if pointer != nil && a.conf.DeleteExtendedPayloads {
+				if err := pointer.delete(rctx, a.s3); err != nil {
+					return err
+				}
+			}
 			select {
 			case <-rctx.Done():
 				return rctx.Err()
@@ -299,3 +299,91 @@ func TestSQSInputBatchAck(t *testing.T) {
 		return msgsLen == 0
 	}, 5*time.Second, time.Second)
 }
+
+func TestSQSInputExtendedPayload(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	r, err := newAWSSQSReader(
+		sqsiConfig{
+			URL:                    "http://foo.example.com",
+			DeleteMessage:          true,
+			ExtendedPayload:        true,
+			DeleteExtendedPayloads: true,
+		},
+		conf,
+		service.MockResources().Logger(),
+	)
+	require.NoError(t, err)
+
+	mockS3 := &mockSQSExtendedPayloadS3{objects: map[string][]byte{
+		"foobucket/fookey": []byte("hello world"),
+	}}
+	r.sqs = &mockSqsInput{}
+	r.s3 = mockS3
+
+	sizeAttr := map[string]types.MessageAttributeValue{
+		sqsExtendedPayloadSizeAttribute: {
+			DataType:    aws.String("Number"),
+			StringValue: aws.String("11"),
+		},
+	}
+	pointerAttrs := map[string]types.MessageAttributeValue{
+		sqsLegacyExtendedPayloadSizeAttribute: sizeAttr[sqsExtendedPayloadSizeAttribute],
+	}
+	for k, v := range sizeAttr {
+		pointerAttrs[k] = v
+	}
+	go func() {
+		for _, m := range []types.Message{
+			{
+				Body:              aws.String(`["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"foobucket","s3Key":"fookey"}]`),
+				MessageId:         aws.String("pointer"),
+				ReceiptHandle:     aws.String("pointer"),
+				MessageAttributes: pointerAttrs,
+			},
+			{
+				Body:              aws.String("not a pointer"),
+				MessageId:         aws.String("malformed"),
+				ReceiptHandle:     aws.String("malformed"),
+				MessageAttributes: sizeAttr,
+			},
+		} {
+			r.messagesChan <- m
+		}
+	}()
+
+	readAndAck := func() (string, *service.Message) {
+		msg, ackFn, err := r.Read(tCtx)
+		require.NoError(t, err)
+
+		acked := make(chan sqsMessageHandle)
+		go func() {
+			acked <- <-r.ackMessagesChan
+		}()
+		require.NoError(t, ackFn(tCtx, nil))
+		<-acked
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		return string(b), msg
+	}
+
+	content, msg := readAndAck()
+	assert.Equal(t, "hello world", content)
+	assert.Empty(t, mockS3.objects)
+	_, exists := msg.MetaGet(sqsExtendedPayloadSizeAttribute)
+	assert.False(t, exists, "size attribute of a resolved pointer should not be kept as metadata")
+	_, exists = msg.MetaGet(sqsLegacyExtendedPayloadSizeAttribute)
+	assert.False(t, exists)
+
+	content, msg = readAndAck()
+	assert.Equal(t, "not a pointer", content)
+	sizeStr, _ := msg.MetaGet(sqsExtendedPayloadSizeAttribute)
+	assert.Equal(t, "11", sizeStr)
+}
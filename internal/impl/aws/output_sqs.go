@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/cenkalti/backoff/v4"
@@ -45,8 +46,12 @@ const (
 	sqsoFieldDelaySeconds    = "delay_seconds"
 	sqsoFieldMetadata        = "metadata"
 	sqsoFieldBatching        = "batching"
+	sqsoFieldExtendedPayload = "extended_payload"
+	sqsoFieldEPBucket        = "bucket"
+	sqsoFieldEPThreshold     = "threshold"
 
 	sqsMaxRecordsCount = 10
+	sqsMaxBatchBytes   = 262144
 )
 
 type sqsoConfig struct {
@@ -55,6 +60,9 @@ type sqsoConfig struct {
 	MessageDeduplicationID *service.InterpolatedString
 	DelaySeconds           *service.InterpolatedString
 
+	ExtendedPayloadBucket    string
+	ExtendedPayloadThreshold int
+
 	Metadata    *service.MetadataExcludeFilter
 	aconf       aws.Config
 	backoffCtor func() backoff.BackOff
@@ -82,6 +90,12 @@ func sqsoConfigFromParsed(pConf *service.ParsedConfig) (conf sqsoConfig, err err
 	if conf.Metadata, err = pConf.FieldMetadataExcludeFilter(sqsoFieldMetadata); err != nil {
 		return
 	}
+	if conf.ExtendedPayloadBucket, err = pConf.FieldString(sqsoFieldExtendedPayload, sqsoFieldEPBucket); err != nil {
+		return
+	}
+	if conf.ExtendedPayloadThreshold, err = pConf.FieldInt(sqsoFieldExtendedPayload, sqsoFieldEPThreshold); err != nil {
+		return
+	}
 	if conf.aconf, err = GetSession(context.TODO(), pConf); err != nil {
 		return
 	}
@@ -100,7 +114,13 @@ func sqsoOutputSpec() *service.ConfigSpec {
 		Description(`
 Metadata values are sent along with the payload as attributes with the data type String. If the number of metadata values in a message exceeds the message attribute limit (10) then the top ten keys ordered alphabetically will be selected.
 
-The fields `+"`message_group_id`, `message_deduplication_id` and `delay_seconds`"+` can be set dynamically using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations], which are resolved individually for each message of a batch.
+Messages of a batch are sent in requests of at most ten messages and 262144 bytes, including their attributes.
+
+The fields `+"`message_group_id`, `message_deduplication_id` and `delay_seconds`"+` can be set dynamically using xref:configuration:interpolation.adoc#bloblang-queries[function interpolations], which are resolved individually for each message of a batch. When writing to a FIFO queue from an `+"`aws_sqs`"+` input the group and deduplication IDs of the consumed messages can be preserved with `+"`${! meta(\"sqs_message_group_id\") }`"+` and `+"`${! meta(\"sqs_message_deduplication_id\") }`"+` respectively.
+
+== Extended payloads
+
+When `+"`"+sqsoFieldExtendedPayload+"."+sqsoFieldEPBucket+"`"+` is set, messages with a payload and attributes larger than `+"`"+sqsoFieldExtendedPayload+"."+sqsoFieldEPThreshold+"`"+` bytes in total are uploaded to the bucket under a random key, and a pointer to the object is sent in their place. Pointer messages follow the format of the AWS extended client libraries, and can therefore be consumed by those libraries as well as the `+"`aws_sqs`"+` input. The pointer carries the attribute `+"`ExtendedPayloadSize`"+`, which counts towards the limit of ten attributes and therefore replaces a metadata value when the limit is reached.
 
 == Credentials
 
@@ -121,6 +141,17 @@ By default Redpanda Connect will use a shared credentials file when connecting t
 			service.NewMetadataExcludeFilterField(snsoFieldMetadata).
 				Description("Specify criteria for which metadata values are sent as headers."),
 			service.NewBatchPolicyField(koFieldBatching),
+			service.NewObjectField(sqsoFieldExtendedPayload,
+				service.NewStringField(sqsoFieldEPBucket).
+					Description("An S3 bucket to offload large payloads to, offloading is disabled when empty.").
+					Default(""),
+				service.NewIntField(sqsoFieldEPThreshold).
+					Description("The size in bytes above which payloads are offloaded, which includes the names, types and values of the message attributes.").
+					Default(262144),
+			).
+				Description("Options for offloading payloads that exceed the SQS size limit to S3.").
+				Version("4.40.0").
+				Advanced(),
 		).
 		Fields(config.SessionFields()...).
 		Fields(retries.CommonRetryBackOffFields(0, "1s", "5s", "30s")...)
@@ -150,6 +181,7 @@ func init() {
 type sqsWriter struct {
 	conf sqsoConfig
 	sqs  sqsAPI
+	s3   sqsExtendedPayloadS3API
 
 	closer    sync.Once
 	closeChan chan struct{}
@@ -172,6 +204,9 @@ func (a *sqsWriter) Connect(ctx context.Context) error {
 	}
 
 	a.sqs = sqs.NewFromConfig(a.conf.aconf)
+	if a.conf.ExtendedPayloadBucket != "" && a.s3 == nil {
+		a.s3 = s3.NewFromConfig(a.conf.aconf)
+	}
 	return nil
 }
 
@@ -189,7 +224,7 @@ func isValidSQSAttribute(k, v string) bool {
 	return len(sqsAttributeKeyInvalidCharRegexp.FindStringIndex(strings.ToLower(k))) == 0
 }
 
-func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttributes, error) {
+func (a *sqsWriter) getSQSAttributes(ctx context.Context, batch service.MessageBatch, i int) (sqsAttributes, error) {
 	msg := batch[i]
 	keys := []string{}
 	_ = a.conf.Metadata.WalkMut(msg, func(k string, v any) error {
@@ -254,15 +289,76 @@ func (a *sqsWriter) getSQSAttributes(batch service.MessageBatch, i int) (sqsAttr
 		return sqsAttributes{}, err
 	}
 
+	content := string(msgBytes)
+	if a.conf.ExtendedPayloadBucket != "" && len(msgBytes)+sqsAttributesSize(values) > a.conf.ExtendedPayloadThreshold {
+		var sizeAttr types.MessageAttributeValue
+		if content, sizeAttr, err = offloadSQSExtendedPayload(ctx, a.s3, a.conf.ExtendedPayloadBucket, msgBytes); err != nil {
+			return sqsAttributes{}, err
+		}
+		if len(values) == 10 {
+			// Make room for the size attribute by dropping the last metadata
+			// key in order.
+			delete(values, keys[9])
+		}
+		if values == nil {
+			values = map[string]types.MessageAttributeValue{}
+		}
+		values[sqsExtendedPayloadSizeAttribute] = sizeAttr
+	}
+
 	return sqsAttributes{
 		attrMap:      values,
 		groupID:      groupID,
 		dedupeID:     dedupeID,
 		delaySeconds: delaySeconds,
-		content:      aws.String(string(msgBytes)),
+		content:      aws.String(content),
 	}, nil
 }
 
+// sqsAttributesSize returns the number of bytes that message attributes count
+// towards the size of a message, which is the length of their names, data
+// types and values combined.
+func sqsAttributesSize(attrs map[string]types.MessageAttributeValue) (n int) {
+	for k, v := range attrs {
+		n += len(k) + len(v.BinaryValue)
+		if v.DataType != nil {
+			n += len(*v.DataType)
+		}
+		if v.StringValue != nil {
+			n += len(*v.StringValue)
+		}
+	}
+	return
+}
+
+func sqsEntrySize(entry types.SendMessageBatchRequestEntry) int {
+	n := sqsAttributesSize(entry.MessageAttributes)
+	if entry.MessageBody != nil {
+		n += len(*entry.MessageBody)
+	}
+	return n
+}
+
+// fillSQSEntries moves pending entries into a request until it either holds
+// the maximum number of entries, or the next entry would take the request
+// beyond the maximum total size. A request always takes at least one entry.
+func fillSQSEntries(request, pending []types.SendMessageBatchRequestEntry) (filled, remaining []types.SendMessageBatchRequestEntry) {
+	var size int
+	for _, e := range request {
+		size += sqsEntrySize(e)
+	}
+	for len(pending) > 0 && len(request) < sqsMaxRecordsCount {
+		eSize := sqsEntrySize(pending[0])
+		if len(request) > 0 && size+eSize > sqsMaxBatchBytes {
+			break
+		}
+		request = append(request, pending[0])
+		pending = pending[1:]
+		size += eSize
+	}
+	return request, pending
+}
+
 func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
 	if a.sqs == nil {
 		return service.ErrNotConnected
@@ -277,7 +373,7 @@ func (a *sqsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch)
 
 	for i := 0; i < len(batch); i++ {
 		id := strconv.Itoa(i)
-		attrs, err := a.getSQSAttributes(batch, i)
+		attrs, err := a.getSQSAttributes(ctx, batch, i)
 		if err != nil {
 			return err
 		}
@@ -317,15 +413,10 @@ func (a *sqsWriter) writeChunk(
 ) error {
 	input := &sqs.SendMessageBatchInput{
 		QueueUrl: &url,
-		Entries:  entries,
 	}
 
-	// trim input length to max sqs batch size
-	if len(entries) > sqsMaxRecordsCount {
-		input.Entries, entries = entries[:sqsMaxRecordsCount], entries[sqsMaxRecordsCount:]
-	} else {
-		entries = nil
-	}
+	// trim input to the max sqs batch count and size
+	input.Entries, entries = fillSQSEntries(nil, entries)
 
 	var err error
 	for len(input.Entries) > 0 {
@@ -384,14 +475,7 @@ func (a *sqsWriter) writeChunk(
 		}
 
 		// add remaining records to batch
-		l := len(input.Entries)
-		if n := len(entries); n > 0 && l < sqsMaxRecordsCount {
-			if remaining := sqsMaxRecordsCount - l; remaining < n {
-				input.Entries, entries = append(input.Entries, entries[:remaining]...), entries[remaining:]
-			} else {
-				input.Entries, entries = append(input.Entries, entries...), nil
-			}
-		}
+		input.Entries, entries = fillSQSEntries(input.Entries, entries)
 	}
 
 	return err
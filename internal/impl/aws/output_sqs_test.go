@@ -15,14 +15,17 @@
 package aws
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/cenkalti/backoff/v4"
@@ -375,3 +378,150 @@ func TestSQSMultipleQueues(t *testing.T) {
 		},
 	}, in)
 }
+
+type mockSQSExtendedPayloadS3 struct {
+	sqsExtendedPayloadS3API
+	objects map[string][]byte
+}
+
+func (m *mockSQSExtendedPayloadS3) PutObject(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[*input.Bucket+"/"+*input.Key] = b
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockSQSExtendedPayloadS3) GetObject(ctx context.Context, input *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b, exists := m.objects[*input.Bucket+"/"+*input.Key]
+	if !exists {
+		return nil, errors.New("object not found")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(b))}, nil
+}
+
+func (m *mockSQSExtendedPayloadS3) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(m.objects, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestSQSExtendedPayload(t *testing.T) {
+	tCtx := context.Background()
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	url, err := service.NewInterpolatedString("http://foo.example.com")
+	require.NoError(t, err)
+	w, err := newSQSWriter(sqsoConfig{
+		URL: url,
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		ExtendedPayloadBucket:    "foobucket",
+		ExtendedPayloadThreshold: 10,
+		aconf:                    conf,
+	}, service.MockResources())
+	require.NoError(t, err)
+
+	mockS3 := &mockSQSExtendedPayloadS3{objects: map[string][]byte{}}
+	w.s3 = mockS3
+
+	var sent []types.SendMessageBatchRequestEntry
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			sent = append(sent, smbi.Entries...)
+			return &sqs.SendMessageBatchOutput{}, nil
+		},
+	}
+
+	withAttr := service.NewMessage([]byte("tiny"))
+	withAttr.MetaSetMut("a", "b")
+
+	require.NoError(t, w.WriteBatch(tCtx, service.MessageBatch{
+		service.NewMessage([]byte("small")),
+		service.NewMessage([]byte("this payload is too large")),
+		withAttr,
+	}))
+	require.Len(t, sent, 3)
+
+	assert.Equal(t, "small", *sent[0].MessageBody)
+	assert.Empty(t, sent[0].MessageAttributes)
+
+	sizeAttr, exists := sent[1].MessageAttributes[sqsExtendedPayloadSizeAttribute]
+	require.True(t, exists)
+	assert.Equal(t, "Number", *sizeAttr.DataType)
+	assert.Equal(t, "25", *sizeAttr.StringValue)
+	assert.Contains(t, *sent[1].MessageBody, sqsExtendedPayloadPointerClass)
+	assert.Len(t, mockS3.objects, 1)
+
+	p, ok, err := sqsExtendedPayloadPointerFromMessage(types.Message{
+		Body:              sent[1].MessageBody,
+		MessageAttributes: sent[1].MessageAttributes,
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "foobucket", p.Bucket)
+
+	payload, err := p.get(tCtx, mockS3)
+	require.NoError(t, err)
+	assert.Equal(t, "this payload is too large", string(payload))
+
+	_, ok, err = sqsExtendedPayloadPointerFromMessage(types.Message{
+		Body: sent[0].MessageBody,
+	})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The payload is below the threshold, but the attributes take the
+	// message beyond it.
+	assert.Contains(t, *sent[2].MessageBody, sqsExtendedPayloadPointerClass)
+	assert.Equal(t, "b", *sent[2].MessageAttributes["a"].StringValue)
+	assert.Equal(t, "4", *sent[2].MessageAttributes[sqsExtendedPayloadSizeAttribute].StringValue)
+	assert.Len(t, mockS3.objects, 2)
+}
+
+func TestSQSSendSizeLimit(t *testing.T) {
+	tCtx := context.Background()
+
+	conf, err := config.LoadDefaultConfig(context.Background(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("xxxxx", "xxxxx", "xxxxx")),
+	)
+	require.NoError(t, err)
+
+	url, err := service.NewInterpolatedString("http://foo.example.com")
+	require.NoError(t, err)
+	w, err := newSQSWriter(sqsoConfig{
+		URL: url,
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		aconf: conf,
+	}, service.MockResources())
+	require.NoError(t, err)
+
+	var in [][]string
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			var ids []string
+			for _, entry := range smbi.Entries {
+				ids = append(ids, *entry.Id)
+			}
+			in = append(in, ids)
+			return &sqs.SendMessageBatchOutput{}, nil
+		},
+	}
+
+	var inMsg service.MessageBatch
+	for i := 0; i < 5; i++ {
+		msg := service.NewMessage(bytes.Repeat([]byte("a"), 100000))
+		msg.MetaSetMut("foo", "bar")
+		inMsg = append(inMsg, msg)
+	}
+	require.NoError(t, w.WriteBatch(tCtx, inMsg))
+
+	assert.Equal(t, [][]string{{"0", "1"}, {"2", "3"}, {"4"}}, in)
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	sqsExtendedPayloadPointerClass = "software.amazon.payloadoffloading.PayloadS3Pointer"
+
+	// The extended client libraries set one of these attributes to the size of
+	// the original payload, which is how offloaded messages are identified.
+	sqsExtendedPayloadSizeAttribute       = "ExtendedPayloadSize"
+	sqsLegacyExtendedPayloadSizeAttribute = "SQSLargePayloadSize"
+)
+
+type sqsExtendedPayloadS3API interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// sqsExtendedPayloadPointer is the location of a payload that was offloaded to
+// S3, following the format of the AWS extended client libraries, where a
+// pointer message body looks like:
+//
+//	["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"foo","s3Key":"bar"}]
+type sqsExtendedPayloadPointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// sqsExtendedPayloadPointerFromMessage attempts to extract an S3 pointer from a
+// message, returning false if the message was not offloaded.
+func sqsExtendedPayloadPointerFromMessage(m types.Message) (p sqsExtendedPayloadPointer, ok bool, err error) {
+	_, isExtended := m.MessageAttributes[sqsExtendedPayloadSizeAttribute]
+	if _, isLegacy := m.MessageAttributes[sqsLegacyExtendedPayloadSizeAttribute]; !isExtended && !isLegacy {
+		return
+	}
+	if m.Body == nil {
+		err = errors.New("extended payload message has no body")
+		return
+	}
+
+	var parts []json.RawMessage
+	if err = json.Unmarshal([]byte(*m.Body), &parts); err != nil {
+		err = fmt.Errorf("failed to parse extended payload pointer: %w", err)
+		return
+	}
+	var class string
+	if len(parts) != 2 || json.Unmarshal(parts[0], &class) != nil || class != sqsExtendedPayloadPointerClass {
+		err = errors.New("failed to parse extended payload pointer: unexpected format")
+		return
+	}
+	if err = json.Unmarshal(parts[1], &p); err != nil {
+		err = fmt.Errorf("failed to parse extended payload pointer: %w", err)
+		return
+	}
+	if p.Bucket == "" || p.Key == "" {
+		err = errors.New("failed to parse extended payload pointer: missing bucket or key")
+		return
+	}
+	ok = true
+	return
+}
+
+func (p sqsExtendedPayloadPointer) body() (string, error) {
+	pBytes, err := json.Marshal([]any{sqsExtendedPayloadPointerClass, p})
+	if err != nil {
+		return "", err
+	}
+	return string(pBytes), nil
+}
+
+func (p sqsExtendedPayloadPointer) get(ctx context.Context, client sqsExtendedPayloadS3API) ([]byte, error) {
+	obj, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &p.Bucket,
+		Key:    &p.Key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extended payload s3://%v/%v: %w", p.Bucket, p.Key, err)
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+func (p sqsExtendedPayloadPointer) delete(ctx context.Context, client sqsExtendedPayloadS3API) error {
+	if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &p.Bucket,
+		Key:    &p.Key,
+	}); err != nil {
+		return fmt.Errorf("failed to delete extended payload s3://%v/%v: %w", p.Bucket, p.Key, err)
+	}
+	return nil
+}
+
+// offloadSQSExtendedPayload uploads a payload to a bucket under a random key,
+// returning the pointer message body and the size attribute to send in its
+// place.
+func offloadSQSExtendedPayload(ctx context.Context, client sqsExtendedPayloadS3API, bucket string, payload []byte) (string, types.MessageAttributeValue, error) {
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return "", types.MessageAttributeValue{}, err
+	}
+	p := sqsExtendedPayloadPointer{Bucket: bucket, Key: u4.String()}
+
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: &p.Bucket,
+		Key:    &p.Key,
+		Body:   bytes.NewReader(payload),
+	}); err != nil {
+		return "", types.MessageAttributeValue{}, fmt.Errorf("failed to offload extended payload to bucket %v: %w", bucket, err)
+	}
+
+	body, err := p.body()
+	if err != nil {
+		return "", types.MessageAttributeValue{}, err
+	}
+	return body, types.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.Itoa(len(payload))),
+	}, nil
+}
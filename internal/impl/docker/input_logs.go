@@ -0,0 +1,653 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/checkpoint"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	dlFieldHost              = "host"
+	dlFieldLabelFilters      = "label_filters"
+	dlFieldStdout            = "stdout"
+	dlFieldStderr            = "stderr"
+	dlFieldStartFromOldest   = "start_from_oldest"
+	dlFieldDiscoveryInterval = "discovery_interval"
+	dlFieldSinceCache        = "since_cache"
+	dlFieldSinceCacheKey     = "since_cache_key_prefix"
+)
+
+func logsInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Local").
+		Version("4.40.0").
+		Summary("Tails the stdout and stderr logs of Docker containers matching a set of label filters.").
+		Description(`
+Containers are discovered by periodically listing the running containers of the Docker daemon that match all of the configured `+"`label_filters`"+`. Each log line of a discovered container is emitted as an individual message, and containers that are started after the input connects are picked up upon the next discovery.
+
+The Docker daemon is connected to using the standard environment variables (`+"`DOCKER_HOST`, `DOCKER_API_VERSION`, `DOCKER_CERT_PATH` and `DOCKER_TLS_VERIFY`"+`), which can be overridden with the field `+"`host`"+`.
+
+== Since persistence
+
+When a `+"`since_cache`"+` is configured the timestamp of the latest log line to be successfully delivered is stored within it for each container, and upon restart each container is resumed directly after that timestamp. Without a cache, or when a container has no stored timestamp, logs are read either from the start of the container or from the point at which the input connected, depending on the field `+"`start_from_oldest`"+`.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+- docker_container_id
+- docker_container_name
+- docker_container_image
+- docker_stream (`+"`stdout`, `stderr`, or `tty`"+` for containers with a TTY attached, where both streams are combined)
+- docker_timestamp
+- All container labels, prefixed with `+"`docker_label_`"+`
+
+You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+`).
+		Fields(
+			service.NewStringField(dlFieldHost).
+				Description("An optional address of the Docker daemon, overriding the `DOCKER_HOST` environment variable.").
+				Example("unix:///var/run/docker.sock").
+				Example("tcp://10.0.0.2:2375").
+				Optional(),
+			service.NewStringListField(dlFieldLabelFilters).
+				Description("A list of label filters that containers must match in order to be tailed, in the form `key` or `key=value`. When empty the logs of all running containers are consumed.").
+				Example([]string{"com.docker.compose.project=shop", "logging"}).
+				Default([]string{}),
+			service.NewBoolField(dlFieldStdout).
+				Description("Whether to consume the stdout stream of containers.").
+				Default(true),
+			service.NewBoolField(dlFieldStderr).
+				Description("Whether to consume the stderr stream of containers.").
+				Default(true),
+			service.NewBoolField(dlFieldStartFromOldest).
+				Description("Whether to read the logs of a container from its start when there is no stored timestamp to resume from. When `false` only log lines written after the input connects are consumed.").
+				Default(false),
+			service.NewDurationField(dlFieldDiscoveryInterval).
+				Description("The period of time between each listing of containers, used for discovering newly started containers.").
+				Default("10s").
+				Advanced(),
+			service.NewStringField(dlFieldSinceCache).
+				Description("An optional xref:components:caches/about.adoc[cache resource] used for storing the timestamp of the latest log line of each container that has been successfully delivered, this allows Redpanda Connect to continue from that point upon restart.").
+				Optional(),
+			service.NewStringField(dlFieldSinceCacheKey).
+				Description("A prefix added to the ID of a container in order to form the key used for storing its timestamp within the `since_cache`.").
+				Default("docker_logs_").
+				Advanced(),
+			service.NewAutoRetryNacksToggleField(),
+		).
+		Example("Compose Project", "Consume the logs of all containers of a compose project, resuming from the last delivered log line of each container upon restarts.", `
+input:
+  docker_logs:
+    label_filters: [ com.docker.compose.project=shop ]
+    since_cache: since
+
+pipeline:
+  processors:
+    - mapping: |
+        root.service = meta("docker_label_com.docker.compose.service")
+        root.stream = meta("docker_stream")
+        root.line = content().string()
+
+cache_resources:
+  - label: since
+    file:
+      directory: /var/lib/redpanda-connect/since
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"docker_logs", logsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			reader, err := newLogsInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, reader)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// logsClient is the subset of the Docker API used by the input.
+type logsClient interface {
+	Ping(ctx context.Context) (types.Ping, error)
+	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+	Close() error
+}
+
+type containerInfo struct {
+	id     string
+	name   string
+	image  string
+	tty    bool
+	labels map[string]string
+}
+
+type logLine struct {
+	container *containerInfo
+	stream    string
+	timestamp time.Time
+	content   []byte
+}
+
+func (l *logLine) toMessage() *service.Message {
+	msg := service.NewMessage(l.content)
+	msg.MetaSetMut("docker_container_id", l.container.id)
+	msg.MetaSetMut("docker_container_name", l.container.name)
+	msg.MetaSetMut("docker_container_image", l.container.image)
+	msg.MetaSetMut("docker_stream", l.stream)
+	msg.MetaSetMut("docker_timestamp", l.timestamp.Format(time.RFC3339Nano))
+	for k, v := range l.container.labels {
+		msg.MetaSetMut("docker_label_"+k, v)
+	}
+	return msg
+}
+
+// parseLogLine splits a log line obtained with timestamps enabled into its
+// timestamp and content.
+func parseLogLine(line string) (time.Time, string, error) {
+	tsStr, content, _ := strings.Cut(line, " ")
+	ts, err := time.Parse(time.RFC3339Nano, tsStr)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("failed to parse log line timestamp: %w", err)
+	}
+	return ts, content, nil
+}
+
+// sinceParam formats a timestamp in the form expected by the since parameter
+// of the logs API.
+func sinceParam(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+//------------------------------------------------------------------------------
+
+type logsInput struct {
+	host              string
+	filters           filters.Args
+	stdout            bool
+	stderr            bool
+	startFromOldest   bool
+	discoveryInterval time.Duration
+	sinceCache        string
+	sinceCacheKey     string
+
+	mgr       *service.Resources
+	log       *service.Logger
+	newClient func() (logsClient, error)
+
+	connMut     sync.Mutex
+	client      logsClient
+	lines       chan *logLine
+	connectedAt time.Time
+	cancelFn    context.CancelFunc
+	tailersWG   sync.WaitGroup
+
+	stateMut      sync.Mutex
+	tailing       map[string]struct{}
+	lastSeen      map[string]time.Time
+	checkpointers map[string]*logsCheckpointer
+}
+
+// logsCheckpointer tracks the delivery of the log lines of a container, and is
+// kept for as long as the container is tailed or has lines pending an ack.
+type logsCheckpointer struct {
+	capped  *checkpoint.Capped[time.Time]
+	pending int
+}
+
+func newLogsInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*logsInput, error) {
+	l := &logsInput{
+		filters:       filters.NewArgs(),
+		mgr:           mgr,
+		log:           mgr.Logger(),
+		tailing:       map[string]struct{}{},
+		lastSeen:      map[string]time.Time{},
+		checkpointers: map[string]*logsCheckpointer{},
+	}
+
+	var err error
+	if conf.Contains(dlFieldHost) {
+		if l.host, err = conf.FieldString(dlFieldHost); err != nil {
+			return nil, err
+		}
+	}
+
+	labelFilters, err := conf.FieldStringList(dlFieldLabelFilters)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range labelFilters {
+		l.filters.Add("label", f)
+	}
+	l.filters.Add("status", "running")
+
+	if l.stdout, err = conf.FieldBool(dlFieldStdout); err != nil {
+		return nil, err
+	}
+	if l.stderr, err = conf.FieldBool(dlFieldStderr); err != nil {
+		return nil, err
+	}
+	if !l.stdout && !l.stderr {
+		return nil, errors.New("at least one of stdout and stderr must be enabled")
+	}
+	if l.startFromOldest, err = conf.FieldBool(dlFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+	if l.discoveryInterval, err = conf.FieldDuration(dlFieldDiscoveryInterval); err != nil {
+		return nil, err
+	}
+	if conf.Contains(dlFieldSinceCache) {
+		if l.sinceCache, err = conf.FieldString(dlFieldSinceCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(l.sinceCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", l.sinceCache)
+		}
+	}
+	if l.sinceCacheKey, err = conf.FieldString(dlFieldSinceCacheKey); err != nil {
+		return nil, err
+	}
+
+	l.newClient = func() (logsClient, error) {
+		opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+		if l.host != "" {
+			opts = append(opts, client.WithHost(l.host))
+		}
+		return client.NewClientWithOpts(opts...)
+	}
+	return l, nil
+}
+
+func (l *logsInput) Connect(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+	if l.client != nil {
+		return nil
+	}
+
+	cli, err := l.newClient()
+	if err != nil {
+		return err
+	}
+	if _, err := cli.Ping(ctx); err != nil {
+		_ = cli.Close()
+		return fmt.Errorf("failed to reach docker daemon: %w", err)
+	}
+
+	discoverCtx, cancelFn := context.WithCancel(context.Background())
+
+	l.client = cli
+	l.lines = make(chan *logLine)
+	l.connectedAt = time.Now()
+	l.cancelFn = cancelFn
+
+	l.tailersWG.Add(1)
+	go l.discoveryLoop(discoverCtx, cli, l.lines)
+	return nil
+}
+
+func (l *logsInput) discoveryLoop(ctx context.Context, cli logsClient, lines chan<- *logLine) {
+	defer l.tailersWG.Done()
+
+	ticker := time.NewTicker(l.discoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.discover(ctx, cli, lines); err != nil && ctx.Err() == nil {
+			l.log.Errorf("Failed to discover containers: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *logsInput) discover(ctx context.Context, cli logsClient, lines chan<- *logLine) error {
+	containers, err := cli.ContainerList(ctx, container.ListOptions{Filters: l.filters})
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		l.stateMut.Lock()
+		_, exists := l.tailing[c.ID]
+		if !exists {
+			l.tailing[c.ID] = struct{}{}
+		}
+		l.stateMut.Unlock()
+		if exists {
+			continue
+		}
+
+		info, err := cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			l.stateMut.Lock()
+			delete(l.tailing, c.ID)
+			l.stateMut.Unlock()
+			l.log.Errorf("Failed to inspect container %v: %v", c.ID, err)
+			continue
+		}
+
+		cInfo := &containerInfo{
+			id:     c.ID,
+			name:   strings.TrimPrefix(info.Name, "/"),
+			image:  c.Image,
+			labels: c.Labels,
+		}
+		if info.Config != nil {
+			cInfo.tty = info.Config.Tty
+		}
+
+		l.log.Debugf("Tailing logs of container %v", cInfo.name)
+		l.tailersWG.Add(1)
+		go func() {
+			defer func() {
+				l.stateMut.Lock()
+				delete(l.tailing, cInfo.id)
+				if c, exists := l.checkpointers[cInfo.id]; exists && c.pending == 0 {
+					delete(l.checkpointers, cInfo.id)
+				}
+				l.stateMut.Unlock()
+				l.tailersWG.Done()
+			}()
+			if err := l.tail(ctx, cli, cInfo, lines); err != nil && ctx.Err() == nil {
+				l.log.Errorf("Failed to tail logs of container %v: %v", cInfo.name, err)
+			}
+		}()
+	}
+
+	return l.pruneRemoved(ctx, cli)
+}
+
+// pruneRemoved removes the last seen timestamps of containers that are no
+// longer tailed and no longer exist. The timestamp of a stopped container is
+// otherwise kept, as it can be restarted, in which case its logs are resumed
+// from it. Existence is checked with a single listing of all containers.
+func (l *logsInput) pruneRemoved(ctx context.Context, cli logsClient) error {
+	l.stateMut.Lock()
+	var stopped []string
+	for id := range l.lastSeen {
+		if _, exists := l.tailing[id]; !exists {
+			stopped = append(stopped, id)
+		}
+	}
+	l.stateMut.Unlock()
+	if len(stopped) == 0 {
+		return nil
+	}
+
+	listFilters := l.filters.Clone()
+	listFilters.Del("status", "running")
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true, Filters: listFilters})
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]struct{}, len(containers))
+	for _, c := range containers {
+		existing[c.ID] = struct{}{}
+	}
+
+	l.stateMut.Lock()
+	for _, id := range stopped {
+		_, exists := existing[id]
+		if _, tailing := l.tailing[id]; !exists && !tailing {
+			delete(l.lastSeen, id)
+		}
+	}
+	l.stateMut.Unlock()
+	return nil
+}
+
+func (l *logsInput) resumeFrom(ctx context.Context, id string) (time.Time, error) {
+	l.stateMut.Lock()
+	ts, exists := l.lastSeen[id]
+	l.stateMut.Unlock()
+	if exists {
+		return ts, nil
+	}
+
+	if l.sinceCache != "" {
+		var tsBytes []byte
+		var cErr error
+		if err := l.mgr.AccessCache(ctx, l.sinceCache, func(c service.Cache) {
+			if tsBytes, cErr = c.Get(ctx, l.sinceCacheKey+id); errors.Is(cErr, service.ErrKeyNotFound) {
+				cErr = nil
+			}
+		}); err != nil {
+			return time.Time{}, err
+		}
+		if cErr != nil {
+			return time.Time{}, cErr
+		}
+		if len(tsBytes) > 0 {
+			return time.Parse(time.RFC3339Nano, string(tsBytes))
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (l *logsInput) tail(ctx context.Context, cli logsClient, c *containerInfo, lines chan<- *logLine) error {
+	since, err := l.resumeFrom(ctx, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to obtain timestamp to resume from: %w", err)
+	}
+
+	// Containers with a TTY attached produce a single raw stream that combines
+	// stdout and stderr, which is recorded by the daemon as stdout.
+	opts := container.LogsOptions{
+		ShowStdout: l.stdout || c.tty,
+		ShowStderr: l.stderr,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		opts.Since = sinceParam(since)
+	} else if !l.startFromOldest {
+		opts.Since = sinceParam(l.connectedAt)
+	}
+
+	rc, err := cli.ContainerLogs(ctx, c.id, opts)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Without a TTY stdout and stderr are multiplexed and must be split.
+	if c.tty {
+		return l.scanLines(ctx, c, "tty", since, rc, lines)
+	}
+
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = outR.CloseWithError(l.scanLines(ctx, c, "stdout", since, outR, lines))
+	}()
+	go func() {
+		defer wg.Done()
+		_ = errR.CloseWithError(l.scanLines(ctx, c, "stderr", since, errR, lines))
+	}()
+
+	_, err = stdcopy.StdCopy(outW, errW, rc)
+	_ = outW.CloseWithError(err)
+	_ = errW.CloseWithError(err)
+	wg.Wait()
+	return err
+}
+
+func (l *logsInput) scanLines(ctx context.Context, c *containerInfo, stream string, since time.Time, r io.Reader, lines chan<- *logLine) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		ts, content, err := parseLogLine(scanner.Text())
+		if err != nil {
+			l.log.Errorf("Skipping log line of container %v: %v", c.name, err)
+			continue
+		}
+		// The since parameter is inclusive, therefore skip the line we've
+		// already delivered.
+		if !since.IsZero() && !ts.After(since) {
+			continue
+		}
+		select {
+		case lines <- &logLine{
+			container: c,
+			stream:    stream,
+			timestamp: ts,
+			content:   []byte(content),
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		l.stateMut.Lock()
+		if prev := l.lastSeen[c.id]; ts.After(prev) {
+			l.lastSeen[c.id] = ts
+		}
+		l.stateMut.Unlock()
+	}
+	return scanner.Err()
+}
+
+// track adds a log line to the checkpointer of its container, and returns a
+// func that releases it, returning the highest timestamp that can be stored.
+func (l *logsInput) track(ctx context.Context, id string, ts time.Time) (func() *time.Time, error) {
+	l.stateMut.Lock()
+	c, exists := l.checkpointers[id]
+	if !exists {
+		c = &logsCheckpointer{capped: checkpoint.NewCapped[time.Time](1024)}
+		l.checkpointers[id] = c
+	}
+	c.pending++
+	l.stateMut.Unlock()
+
+	release, err := c.capped.Track(ctx, ts, 1)
+	if err != nil {
+		l.untrack(id, c)
+		return nil, err
+	}
+	return func() *time.Time {
+		highest := release()
+		l.untrack(id, c)
+		return highest
+	}, nil
+}
+
+// untrack removes a pending line from a checkpointer, which is dropped once
+// its container is no longer tailed and no lines remain pending.
+func (l *logsInput) untrack(id string, c *logsCheckpointer) {
+	l.stateMut.Lock()
+	defer l.stateMut.Unlock()
+
+	if c.pending--; c.pending > 0 {
+		return
+	}
+	if _, tailing := l.tailing[id]; !tailing && l.checkpointers[id] == c {
+		delete(l.checkpointers, id)
+	}
+}
+
+func (l *logsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	l.connMut.Lock()
+	lines := l.lines
+	l.connMut.Unlock()
+	if lines == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var line *logLine
+	select {
+	case line = <-lines:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	if l.sinceCache == "" {
+		return line.toMessage(), func(context.Context, error) error { return nil }, nil
+	}
+
+	release, err := l.track(ctx, line.container.id, line.timestamp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := l.sinceCacheKey + line.container.id
+	return line.toMessage(), func(ctx context.Context, err error) error {
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		var setErr error
+		if err := l.mgr.AccessCache(ctx, l.sinceCache, func(c service.Cache) {
+			setErr = c.Set(ctx, key, []byte(highest.Format(time.RFC3339Nano)), nil)
+		}); err != nil {
+			return err
+		}
+		return setErr
+	}, nil
+}
+
+func (l *logsInput) Close(ctx context.Context) error {
+	l.connMut.Lock()
+	cli, cancelFn := l.client, l.cancelFn
+	l.client, l.lines, l.cancelFn = nil, nil, nil
+	l.connMut.Unlock()
+
+	if cli == nil {
+		return nil
+	}
+	cancelFn()
+
+	done := make(chan struct{})
+	go func() {
+		l.tailersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return cli.Close()
+}
@@ -0,0 +1,336 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type fakeContainer struct {
+	running bool
+	tty     bool
+	stdout  []string
+	stderr  []string
+}
+
+type fakeLogsClient struct {
+	mut        sync.Mutex
+	containers map[string]*fakeContainer
+	logsOpts   map[string]container.LogsOptions
+}
+
+func (f *fakeLogsClient) Ping(ctx context.Context) (types.Ping, error) {
+	return types.Ping{}, nil
+}
+
+func (f *fakeLogsClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var list []types.Container
+	for id, c := range f.containers {
+		if c.running || options.All {
+			list = append(list, types.Container{ID: id, Image: "foo:latest"})
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeLogsClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	c, exists := f.containers[containerID]
+	if !exists {
+		return types.ContainerJSON{}, errors.New("no such container")
+	}
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Name: "/" + containerID},
+		Config:            &container.Config{Tty: c.tty},
+	}, nil
+}
+
+func (f *fakeLogsClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.logsOpts[containerID] = options
+	c := f.containers[containerID]
+
+	var buf bytes.Buffer
+	if c.tty {
+		if options.ShowStdout {
+			for _, line := range c.stdout {
+				buf.WriteString(line + "\n")
+			}
+		}
+		return io.NopCloser(&buf), nil
+	}
+
+	if options.ShowStdout {
+		w := stdcopy.NewStdWriter(&buf, stdcopy.Stdout)
+		_, _ = w.Write([]byte(strings.Join(c.stdout, "\n") + "\n"))
+	}
+	if options.ShowStderr {
+		w := stdcopy.NewStdWriter(&buf, stdcopy.Stderr)
+		_, _ = w.Write([]byte(strings.Join(c.stderr, "\n") + "\n"))
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func (f *fakeLogsClient) Close() error {
+	return nil
+}
+
+func TestDockerLogsParseLine(t *testing.T) {
+	ts, content, err := parseLogLine("2024-11-07T10:00:01.123456789Z hello world")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", content)
+	assert.Equal(t, time.Date(2024, 11, 7, 10, 0, 1, 123456789, time.UTC), ts)
+	assert.Equal(t, "1730973601.123456789", sinceParam(ts))
+
+	_, content, err = parseLogLine("2024-11-07T10:00:01Z")
+	require.NoError(t, err)
+	assert.Equal(t, "", content)
+
+	_, _, err = parseLogLine("not a timestamp")
+	require.Error(t, err)
+}
+
+func TestDockerLogsLineToMessage(t *testing.T) {
+	line := &logLine{
+		container: &containerInfo{
+			id:    "abc",
+			name:  "web",
+			image: "nginx:latest",
+			labels: map[string]string{
+				"com.docker.compose.service": "frontend",
+			},
+		},
+		stream:    "stderr",
+		timestamp: time.Date(2024, 11, 7, 10, 0, 1, 0, time.UTC),
+		content:   []byte("hello world"),
+	}
+
+	msg := line.toMessage()
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	for k, v := range map[string]string{
+		"docker_container_id":                     "abc",
+		"docker_container_name":                   "web",
+		"docker_container_image":                  "nginx:latest",
+		"docker_stream":                           "stderr",
+		"docker_timestamp":                        "2024-11-07T10:00:01Z",
+		"docker_label_com.docker.compose.service": "frontend",
+	} {
+		actual, exists := msg.MetaGet(k)
+		assert.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+}
+
+func TestDockerLogsConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+stdout: false
+stderr: false
+`,
+		`
+since_cache: nope
+`,
+	} {
+		pConf, err := logsInputConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newLogsInputFromConfig(pConf, service.MockResources())
+		require.Error(t, err, conf)
+	}
+}
+
+func TestDockerLogsTailAckPrune(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("since"))
+	pConf, err := logsInputConfig().ParseYAML(`
+since_cache: since
+start_from_oldest: true
+discovery_interval: 1h
+`, nil)
+	require.NoError(t, err)
+
+	l, err := newLogsInputFromConfig(pConf, mgr)
+	require.NoError(t, err)
+
+	fake := &fakeLogsClient{
+		containers: map[string]*fakeContainer{
+			"c1": {
+				running: true,
+				stdout: []string{
+					"2024-11-07T10:00:01Z hello",
+					"2024-11-07T10:00:02Z world",
+				},
+			},
+		},
+		logsOpts: map[string]container.LogsOptions{},
+	}
+	l.newClient = func() (logsClient, error) {
+		return fake, nil
+	}
+
+	require.NoError(t, l.Connect(ctx))
+	t.Cleanup(func() {
+		_ = l.Close(context.Background())
+	})
+
+	var contents []string
+	var acks []service.AckFunc
+	for i := 0; i < 2; i++ {
+		msg, ackFn, err := l.Read(ctx)
+		require.NoError(t, err)
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, string(b))
+		acks = append(acks, ackFn)
+	}
+	assert.Equal(t, []string{"hello", "world"}, contents)
+
+	// The log stream ends whilst its lines are still pending, and so the
+	// checkpointer of the container must be kept.
+	require.Eventually(t, func() bool {
+		l.stateMut.Lock()
+		defer l.stateMut.Unlock()
+		_, tailing := l.tailing["c1"]
+		return !tailing
+	}, time.Second*5, time.Millisecond*10)
+
+	l.stateMut.Lock()
+	assert.Contains(t, l.checkpointers, "c1")
+	l.stateMut.Unlock()
+
+	for _, ackFn := range acks {
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	l.stateMut.Lock()
+	assert.Empty(t, l.checkpointers)
+	l.stateMut.Unlock()
+
+	var since []byte
+	require.NoError(t, mgr.AccessCache(ctx, "since", func(c service.Cache) {
+		since, err = c.Get(ctx, "docker_logs_c1")
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-11-07T10:00:02Z", string(since))
+
+	// A stopped container keeps its last seen timestamp until it is removed.
+	fake.mut.Lock()
+	fake.containers["c1"].running = false
+	fake.mut.Unlock()
+
+	require.NoError(t, l.discover(ctx, fake, l.lines))
+	l.stateMut.Lock()
+	assert.Contains(t, l.lastSeen, "c1")
+	l.stateMut.Unlock()
+
+	fake.mut.Lock()
+	delete(fake.containers, "c1")
+	fake.mut.Unlock()
+
+	require.NoError(t, l.discover(ctx, fake, l.lines))
+	l.stateMut.Lock()
+	assert.Empty(t, l.lastSeen)
+	l.stateMut.Unlock()
+}
+
+func TestDockerLogsStreams(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	pConf, err := logsInputConfig().ParseYAML(`
+stdout: false
+start_from_oldest: true
+discovery_interval: 1h
+`, nil)
+	require.NoError(t, err)
+
+	l, err := newLogsInputFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	fake := &fakeLogsClient{
+		containers: map[string]*fakeContainer{
+			"plain": {
+				running: true,
+				stdout:  []string{"2024-11-07T10:00:01Z out"},
+				stderr:  []string{"2024-11-07T10:00:02Z err"},
+			},
+			"term": {
+				running: true,
+				tty:     true,
+				stdout:  []string{"2024-11-07T10:00:03Z combined"},
+			},
+		},
+		logsOpts: map[string]container.LogsOptions{},
+	}
+	l.newClient = func() (logsClient, error) {
+		return fake, nil
+	}
+
+	require.NoError(t, l.Connect(ctx))
+	t.Cleanup(func() {
+		_ = l.Close(context.Background())
+	})
+
+	streams := map[string]string{}
+	for i := 0; i < 2; i++ {
+		msg, _, err := l.Read(ctx)
+		require.NoError(t, err)
+
+		id, _ := msg.MetaGet("docker_container_id")
+		stream, _ := msg.MetaGet("docker_stream")
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		streams[id] = stream + ":" + string(b)
+	}
+
+	assert.Equal(t, map[string]string{
+		"plain": "stderr:err",
+		"term":  "tty:combined",
+	}, streams)
+
+	fake.mut.Lock()
+	assert.False(t, fake.logsOpts["plain"].ShowStdout)
+	assert.True(t, fake.logsOpts["term"].ShowStdout)
+	fake.mut.Unlock()
+}
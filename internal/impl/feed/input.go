@@ -0,0 +1,389 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	fiFieldURLs           = "urls"
+	fiFieldPollInterval   = "poll_interval"
+	fiFieldTimeout        = "timeout"
+	fiFieldCache          = "cache"
+	fiFieldCacheKeyPrefix = "cache_key_prefix"
+)
+
+func inputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Network").
+		Version("4.40.0").
+		Summary("Polls RSS and Atom feeds and emits each new entry as a structured message.").
+		Description(`
+Each of the `+"`"+fiFieldURLs+"`"+` is fetched once every `+"`"+fiFieldPollInterval+"`"+`, and entries that have not been seen before are emitted oldest first, ordered by their `+"`published`"+` timestamp, or their `+"`updated`"+` timestamp when not published. When an entry of a feed has neither timestamp the new entries of that feed are instead emitted in the reverse of the order they are listed in, as feeds typically list their newest entries first. RSS 1.0, RSS 2.0 and Atom documents are supported, and the entries of each are normalised into the same structure:
+
+`+"```json"+`
+{
+  "id": "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a",
+  "title": "Release notes",
+  "link": "https://example.com/posts/release-notes",
+  "description": "A summary of the entry",
+  "content": "The full content of the entry, when provided",
+  "authors": [ "Jane Doe" ],
+  "categories": [ "releases" ],
+  "published": "2024-11-07T09:00:00Z",
+  "updated": "2024-11-07T10:00:00Z",
+  "feed": {
+    "title": "Example Blog",
+    "link": "https://example.com",
+    "url": "https://example.com/feed.xml"
+  }
+}
+`+"```"+`
+
+The ID of an entry is its `+"`guid`"+` (RSS) or `+"`id`"+` (Atom), falling back to its link, and the fields `+"`published`"+` and `+"`updated`"+` are formatted as RFC 3339 timestamps when they can be parsed, and omitted when absent.
+
+== Caching
+
+The `+"`ETag`"+` and `+"`Last-Modified`"+` headers of each response are sent back with the following request of the same URL, allowing servers to respond without a body when a feed has not changed.
+
+The IDs of the entries within the latest fetch of each feed are held in memory in order to skip entries that were already emitted. In order to also skip them across restarts a `+"`"+fiFieldCache+"`"+` resource can be configured, in which case a key is stored within it for each entry once its message has been successfully delivered, and entries with an existing key are skipped. Without a cache all entries of each feed are emitted upon startup.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+- feed_url
+- feed_title
+- feed_item_id
+
+You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+`).
+		Fields(
+			service.NewStringListField(fiFieldURLs).
+				Description("A list of RSS or Atom feed URLs to poll.").
+				Example([]string{"https://example.com/feed.xml"}),
+			service.NewDurationField(fiFieldPollInterval).
+				Description("The period of time between each poll of the feeds.").
+				Default("5m"),
+			service.NewDurationField(fiFieldTimeout).
+				Description("The maximum period of time to wait for a feed to be fetched.").
+				Default("30s").
+				Advanced(),
+			service.NewStringField(fiFieldCache).
+				Description("An optional xref:components:caches/about.adoc[cache resource] used for recording the entries that have been delivered, allowing entries to be deduplicated across restarts.").
+				Optional(),
+			service.NewStringField(fiFieldCacheKeyPrefix).
+				Description("A prefix added to a hash of the feed URL and entry ID in order to form the key of an entry within the `cache`.").
+				Default("feed_").
+				Advanced(),
+			service.NewAutoRetryNacksToggleField(),
+		).
+		Example("Release Announcements", "Post the new entries of a project blog to a Discord channel, remembering the delivered entries across restarts.", `
+input:
+  feed:
+    urls: [ https://example.com/blog/feed.xml ]
+    poll_interval: 15m
+    cache: seen
+
+pipeline:
+  processors:
+    - mapping: |
+        root = "%s: %s".format(this.title, this.link)
+
+output:
+  discord:
+    channel_id: ${DISCORD_CHANNEL}
+    bot_token: ${DISCORD_BOT_TOKEN}
+
+cache_resources:
+  - label: seen
+    file:
+      directory: /var/lib/redpanda-connect/feeds
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"feed", inputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			reader, err := newInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, reader)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type feedState struct {
+	etag         string
+	lastModified string
+	seen         map[string]struct{}
+}
+
+type pendingItem struct {
+	url  string
+	feed *feed
+	item feedItem
+}
+
+type input struct {
+	urls           []string
+	pollInterval   time.Duration
+	cache          string
+	cacheKeyPrefix string
+
+	log    *service.Logger
+	mgr    *service.Resources
+	client *http.Client
+
+	states   map[string]*feedState
+	pending  []pendingItem
+	nextPoll time.Time
+}
+
+func newInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*input, error) {
+	i := &input{
+		log:    mgr.Logger(),
+		mgr:    mgr,
+		states: map[string]*feedState{},
+	}
+
+	var err error
+	if i.urls, err = conf.FieldStringList(fiFieldURLs); err != nil {
+		return nil, err
+	}
+	if len(i.urls) == 0 {
+		return nil, errors.New("at least one url must be specified")
+	}
+	if i.pollInterval, err = conf.FieldDuration(fiFieldPollInterval); err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration(fiFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	i.client = &http.Client{Timeout: timeout}
+	if conf.Contains(fiFieldCache) {
+		if i.cache, err = conf.FieldString(fiFieldCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(i.cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", i.cache)
+		}
+	}
+	if i.cacheKeyPrefix, err = conf.FieldString(fiFieldCacheKeyPrefix); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (i *input) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (i *input) cacheKey(url, id string) string {
+	h := sha256.Sum256([]byte(url + "\n" + id))
+	return i.cacheKeyPrefix + hex.EncodeToString(h[:])
+}
+
+// delivered returns true if an entry is recorded as delivered within the cache.
+func (i *input) delivered(ctx context.Context, url, id string) (exists bool, err error) {
+	if cErr := i.mgr.AccessCache(ctx, i.cache, func(c service.Cache) {
+		if _, err = c.Get(ctx, i.cacheKey(url, id)); err == nil {
+			exists = true
+		} else if errors.Is(err, service.ErrKeyNotFound) {
+			err = nil
+		}
+	}); cErr != nil {
+		return false, cErr
+	}
+	return
+}
+
+// fetch obtains the latest version of a feed, returning nil if it has not
+// been modified since it was last fetched. The ETag and Last-Modified headers
+// of the response are returned within a new state, which should only replace
+// the current state once the entries of the feed have been collected.
+func (i *input) fetch(ctx context.Context, url string, state *feedState) (*feed, *feedState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/rdf+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.8")
+	if state.etag != "" {
+		req.Header.Set("If-None-Match", state.etag)
+	}
+	if state.lastModified != "" {
+		req.Header.Set("If-Modified-Since", state.lastModified)
+	}
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, nil, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("unexpected status code: %v", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	return f, &feedState{
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// poll fetches a feed and returns the entries that have not been seen before,
+// oldest first.
+func (i *input) poll(ctx context.Context, url string) ([]pendingItem, error) {
+	state, exists := i.states[url]
+	if !exists {
+		state = &feedState{seen: map[string]struct{}{}}
+		i.states[url] = state
+	}
+
+	f, newState, err := i.fetch(ctx, url, state)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	// Only the entries of the latest fetch are remembered, as entries that
+	// drop out of a feed do not typically return.
+	seen := make(map[string]struct{}, len(f.Items))
+	var items []pendingItem
+	for _, item := range f.Items {
+		if _, exists := seen[item.ID]; exists {
+			continue
+		}
+		seen[item.ID] = struct{}{}
+		if _, exists := state.seen[item.ID]; exists {
+			continue
+		}
+		if i.cache != "" {
+			delivered, err := i.delivered(ctx, url, item.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check cache: %w", err)
+			}
+			if delivered {
+				continue
+			}
+		}
+		items = append(items, pendingItem{url: url, feed: f, item: item})
+	}
+	newState.seen = seen
+	i.states[url] = newState
+
+	sortPendingItems(items)
+	return items, nil
+}
+
+// sortPendingItems orders the new entries of a feed oldest first by their
+// timestamps, or reverses them when any entry lacks a timestamp.
+func sortPendingItems(items []pendingItem) {
+	slices.Reverse(items)
+
+	times := make(map[string]time.Time, len(items))
+	for _, p := range items {
+		t, ok := p.item.time()
+		if !ok {
+			return
+		}
+		times[p.item.ID] = t
+	}
+	slices.SortStableFunc(items, func(a, b pendingItem) int {
+		return times[a.item.ID].Compare(times[b.item.ID])
+	})
+}
+
+func (i *input) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	for len(i.pending) == 0 {
+		if wait := time.Until(i.nextPoll); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		i.nextPoll = time.Now().Add(i.pollInterval)
+
+		for _, url := range i.urls {
+			items, err := i.poll(ctx, url)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				i.log.Errorf("Failed to poll feed '%v': %v", url, err)
+				continue
+			}
+			i.pending = append(i.pending, items...)
+		}
+	}
+
+	next := i.pending[0]
+	i.pending = i.pending[1:]
+
+	msg := service.NewMessage(nil)
+	msg.SetStructuredMut(next.item.structured(next.feed, next.url))
+	msg.MetaSetMut("feed_url", next.url)
+	msg.MetaSetMut("feed_title", next.feed.Title)
+	msg.MetaSetMut("feed_item_id", next.item.ID)
+
+	return msg, func(ctx context.Context, err error) error {
+		if err != nil || i.cache == "" {
+			return nil
+		}
+		var setErr error
+		if cErr := i.mgr.AccessCache(ctx, i.cache, func(c service.Cache) {
+			setErr = c.Set(ctx, i.cacheKey(next.url, next.item.ID), []byte(next.item.ID), nil)
+		}); cErr != nil {
+			return cErr
+		}
+		return setErr
+	}, nil
+}
+
+func (i *input) Close(ctx context.Context) error {
+	return nil
+}
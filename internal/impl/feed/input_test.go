@@ -0,0 +1,202 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const testRSS = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Example Blog</title>
+    <link>https://example.com</link>
+    <item>
+      <title>Second</title>
+      <link>https://example.com/2</link>
+      <guid>2</guid>
+      <pubDate>Thu, 07 Nov 2024 10:00:00 GMT</pubDate>
+      <dc:creator>Jane Doe</dc:creator>
+      <category>releases</category>
+    </item>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+      <pubDate>Wed, 6 Nov 2024 10:00:00 +0100</pubDate>
+    </item>
+  </channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Atom Blog</title>
+  <link rel="self" href="https://example.com/feed.atom"/>
+  <link href="https://example.com/"/>
+  <entry>
+    <title>Entry</title>
+    <link rel="alternate" href="https://example.com/entry"/>
+    <id>urn:uuid:1</id>
+    <updated>2024-11-07T10:00:00Z</updated>
+    <summary>A summary</summary>
+    <author><name>Bob</name></author>
+    <category term="go"/>
+  </entry>
+</feed>`
+
+func TestFeedParse(t *testing.T) {
+	f, err := parseFeed([]byte(testRSS))
+	require.NoError(t, err)
+	assert.Equal(t, "Example Blog", f.Title)
+	assert.Equal(t, []feedItem{
+		{
+			ID:         "2",
+			Title:      "Second",
+			Link:       "https://example.com/2",
+			Authors:    []string{"Jane Doe"},
+			Categories: []string{"releases"},
+			Published:  "2024-11-07T10:00:00Z",
+		},
+		{
+			ID:         "https://example.com/1",
+			Title:      "First",
+			Link:       "https://example.com/1",
+			Authors:    []string{},
+			Categories: []string{},
+			Published:  "2024-11-06T10:00:00+01:00",
+		},
+	}, f.Items)
+
+	f, err = parseFeed([]byte(testAtom))
+	require.NoError(t, err)
+	assert.Equal(t, "Atom Blog", f.Title)
+	assert.Equal(t, "https://example.com/", f.Link)
+	assert.Equal(t, []feedItem{
+		{
+			ID:          "urn:uuid:1",
+			Title:       "Entry",
+			Link:        "https://example.com/entry",
+			Description: "A summary",
+			Authors:     []string{"Bob"},
+			Categories:  []string{"go"},
+			Updated:     "2024-11-07T10:00:00Z",
+		},
+	}, f.Items)
+
+	_, err = parseFeed([]byte(`<html><body>nope</body></html>`))
+	require.Error(t, err)
+}
+
+func TestFeedInputRead(t *testing.T) {
+	var mut sync.Mutex
+	body, notModified := testRSS, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body)))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	conf, err := inputConfig().ParseYAML(`
+urls: [ `+srv.URL+` ]
+poll_interval: 1ms
+cache: seen
+`, nil)
+	require.NoError(t, err)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("seen"))
+
+	readTitles := func(i *input, n int) []string {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+		defer done()
+
+		var titles []string
+		for len(titles) < n {
+			msg, ackFn, err := i.Read(ctx)
+			require.NoError(t, err)
+			v, err := msg.AsStructured()
+			require.NoError(t, err)
+			titles = append(titles, v.(map[string]any)["title"].(string))
+			require.NoError(t, ackFn(ctx, nil))
+		}
+		return titles
+	}
+
+	readNothing := func(i *input) {
+		ctx, done := context.WithTimeout(context.Background(), time.Millisecond*100)
+		defer done()
+		_, _, err := i.Read(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	i, err := newInputFromConfig(conf, mgr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second"}, readTitles(i, 2))
+
+	readNothing(i)
+	mut.Lock()
+	assert.Positive(t, notModified)
+	body = `<rss><channel><title>Example Blog</title><item><guid>3</guid><title>Third</title></item><item><guid>2</guid><title>Second</title></item></channel></rss>`
+	mut.Unlock()
+	assert.Equal(t, []string{"Third"}, readTitles(i, 1))
+
+	// A new input sharing the cache skips entries that were delivered.
+	i, err = newInputFromConfig(conf, mgr)
+	require.NoError(t, err)
+	readNothing(i)
+}
+
+func TestFeedSortPendingItems(t *testing.T) {
+	ids := func(items []pendingItem) (res []string) {
+		for _, p := range items {
+			res = append(res, p.item.ID)
+		}
+		return
+	}
+
+	items := []pendingItem{
+		{item: feedItem{ID: "b", Published: "2024-11-06T10:00:00Z"}},
+		{item: feedItem{ID: "c", Updated: "2024-11-07T10:00:00Z"}},
+		{item: feedItem{ID: "a", Published: "2024-11-05T10:00:00Z"}},
+	}
+	sortPendingItems(items)
+	assert.Equal(t, []string{"a", "b", "c"}, ids(items))
+
+	items = []pendingItem{
+		{item: feedItem{ID: "b", Published: "2024-11-06T10:00:00Z"}},
+		{item: feedItem{ID: "c"}},
+		{item: feedItem{ID: "a", Published: "2024-11-05T10:00:00Z"}},
+	}
+	sortPendingItems(items)
+	assert.Equal(t, []string{"a", "c", "b"}, ids(items))
+}
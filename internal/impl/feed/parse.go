@@ -0,0 +1,329 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// feed is the normalised form of an RSS or Atom document.
+type feed struct {
+	Title string
+	Link  string
+	Items []feedItem
+}
+
+type feedItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Content     string
+	Authors     []string
+	Categories  []string
+	Published   string
+	Updated     string
+}
+
+// time returns the published time of an item, or its updated time when it has
+// not been published.
+func (i feedItem) time() (time.Time, bool) {
+	for _, s := range []string{i.Published, i.Updated} {
+		if s == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (i feedItem) structured(f *feed, url string) map[string]any {
+	authors := make([]any, 0, len(i.Authors))
+	for _, a := range i.Authors {
+		authors = append(authors, a)
+	}
+	categories := make([]any, 0, len(i.Categories))
+	for _, c := range i.Categories {
+		categories = append(categories, c)
+	}
+	v := map[string]any{
+		"id":          i.ID,
+		"title":       i.Title,
+		"link":        i.Link,
+		"description": i.Description,
+		"content":     i.Content,
+		"authors":     authors,
+		"categories":  categories,
+		"feed": map[string]any{
+			"title": f.Title,
+			"link":  f.Link,
+			"url":   url,
+		},
+	}
+	if i.Published != "" {
+		v["published"] = i.Published
+	}
+	if i.Updated != "" {
+		v["updated"] = i.Updated
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+type rssItem struct {
+	GUID        string   `xml:"guid"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string   `xml:"author"`
+	Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Link  string    `xml:"link"`
+	Items []rssItem `xml:"item"`
+}
+
+// rssDocument covers both RSS 2.0, where items are children of the channel,
+// and RSS 1.0 (RDF), where items are siblings of the channel.
+type rssDocument struct {
+	Channel rssChannel `xml:"channel"`
+	Items   []rssItem  `xml:"item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary"`
+	Content    string         `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomAlternateLink returns the link of an Atom element that points to its
+// human readable representation, which is the link with a rel of alternate or
+// with no rel at all.
+func atomAlternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// normaliseFeedTime attempts to parse the many date formats found in feeds in
+// order to format them as RFC 3339, returning the original string when the
+// format is unknown.
+func normaliseFeedTime(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// fallbackItemID derives an ID for items that have neither an ID nor a link.
+func fallbackItemID(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		_, _ = h.Write([]byte(p))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newFeedDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	return dec
+}
+
+// parseFeed parses an RSS 1.0, RSS 2.0 or Atom document.
+func parseFeed(data []byte) (*feed, error) {
+	dec := newFeedDecoder(bytes.NewReader(data))
+
+	var root xml.StartElement
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("document has no root element")
+			}
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			root = se
+			break
+		}
+	}
+
+	switch root.Name.Local {
+	case "rss", "RDF":
+		var doc rssDocument
+		if err := dec.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		return rssToFeed(doc), nil
+	case "feed":
+		var doc atomDocument
+		if err := dec.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		return atomToFeed(doc), nil
+	}
+	return nil, fmt.Errorf("unrecognised feed root element: %v", root.Name.Local)
+}
+
+func rssToFeed(doc rssDocument) *feed {
+	f := &feed{
+		Title: strings.TrimSpace(doc.Channel.Title),
+		Link:  strings.TrimSpace(doc.Channel.Link),
+	}
+	for _, ri := range append(doc.Channel.Items, doc.Items...) {
+		item := feedItem{
+			ID:          strings.TrimSpace(ri.GUID),
+			Title:       strings.TrimSpace(ri.Title),
+			Link:        strings.TrimSpace(ri.Link),
+			Description: strings.TrimSpace(ri.Description),
+			Content:     strings.TrimSpace(ri.Content),
+			Categories:  []string{},
+			Authors:     []string{},
+		}
+		if a := strings.TrimSpace(ri.Author); a != "" {
+			item.Authors = append(item.Authors, a)
+		}
+		for _, c := range ri.Creators {
+			if c = strings.TrimSpace(c); c != "" {
+				item.Authors = append(item.Authors, c)
+			}
+		}
+		for _, c := range ri.Categories {
+			if c = strings.TrimSpace(c); c != "" {
+				item.Categories = append(item.Categories, c)
+			}
+		}
+		if ri.PubDate != "" {
+			item.Published = normaliseFeedTime(ri.PubDate)
+		} else {
+			item.Published = normaliseFeedTime(ri.Date)
+		}
+		if item.ID == "" {
+			item.ID = item.Link
+		}
+		if item.ID == "" {
+			item.ID = fallbackItemID(item.Title, item.Description, item.Published)
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+func atomToFeed(doc atomDocument) *feed {
+	f := &feed{
+		Title: strings.TrimSpace(doc.Title),
+		Link:  strings.TrimSpace(atomAlternateLink(doc.Links)),
+	}
+	for _, ae := range doc.Entries {
+		item := feedItem{
+			ID:          strings.TrimSpace(ae.ID),
+			Title:       strings.TrimSpace(ae.Title),
+			Link:        strings.TrimSpace(atomAlternateLink(ae.Links)),
+			Description: strings.TrimSpace(ae.Summary),
+			Content:     strings.TrimSpace(ae.Content),
+			Categories:  []string{},
+			Authors:     []string{},
+			Published:   normaliseFeedTime(ae.Published),
+			Updated:     normaliseFeedTime(ae.Updated),
+		}
+		for _, a := range ae.Authors {
+			if n := strings.TrimSpace(a.Name); n != "" {
+				item.Authors = append(item.Authors, n)
+			}
+		}
+		for _, c := range ae.Categories {
+			if t := strings.TrimSpace(c.Term); t != "" {
+				item.Categories = append(item.Categories, t)
+			}
+		}
+		if item.ID == "" {
+			item.ID = item.Link
+		}
+		if item.ID == "" {
+			item.ID = fallbackItemID(item.Title, item.Description, item.Updated)
+		}
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
@@ -17,8 +17,10 @@ package gcp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"google.golang.org/api/option"
@@ -35,6 +37,10 @@ const (
 	pbiFieldMaxOutstandingMessages = "max_outstanding_messages"
 	pbiFieldMaxOutstandingBytes    = "max_outstanding_bytes"
 	pbiFieldSync                   = "sync"
+	pbiFieldMaxExtension           = "max_extension"
+	pbiFieldMaxExtensionPeriod     = "max_extension_period"
+	pbiFieldMinExtensionPeriod     = "min_extension_period"
+	pbiFieldExactlyOnce            = "exactly_once"
 	pbiFieldCreateSub              = "create_subscription"
 	pbiFieldCreateSubEnabled       = "enabled"
 	pbiFieldCreateSubTopicID       = "topic"
@@ -48,6 +54,10 @@ type pbiConfig struct {
 	MaxOutstandingMessages int
 	MaxOutstandingBytes    int
 	Sync                   bool
+	MaxExtension           time.Duration
+	MaxExtensionPeriod     time.Duration
+	MinExtensionPeriod     time.Duration
+	ExactlyOnce            bool
 	CreateEnabled          bool
 	CreateTopicID          string
 }
@@ -74,6 +84,18 @@ func pbiConfigFromParsed(pConf *service.ParsedConfig) (conf pbiConfig, err error
 	if conf.Sync, err = pConf.FieldBool(pbiFieldSync); err != nil {
 		return
 	}
+	if conf.MaxExtension, err = pConf.FieldDuration(pbiFieldMaxExtension); err != nil {
+		return
+	}
+	if conf.MaxExtensionPeriod, err = pConf.FieldDuration(pbiFieldMaxExtensionPeriod); err != nil {
+		return
+	}
+	if conf.MinExtensionPeriod, err = pConf.FieldDuration(pbiFieldMinExtensionPeriod); err != nil {
+		return
+	}
+	if conf.ExactlyOnce, err = pConf.FieldBool(pbiFieldExactlyOnce); err != nil {
+		return
+	}
 	if pConf.Contains(pbiFieldCreateSub) {
 		createConf := pConf.Namespace(pbiFieldCreateSub)
 		if conf.CreateEnabled, err = createConf.FieldBool(pbiFieldCreateSubEnabled); err != nil {
@@ -86,6 +108,15 @@ func pbiConfigFromParsed(pConf *service.ParsedConfig) (conf pbiConfig, err error
 	return
 }
 
+func (conf pbiConfig) applyReceiveSettings(settings *pubsub.ReceiveSettings) {
+	settings.MaxOutstandingMessages = conf.MaxOutstandingMessages
+	settings.MaxOutstandingBytes = conf.MaxOutstandingBytes
+	settings.Synchronous = conf.Sync
+	settings.MaxExtension = conf.MaxExtension
+	settings.MaxExtensionPeriod = conf.MaxExtensionPeriod
+	settings.MinExtensionPeriod = conf.MinExtensionPeriod
+}
+
 func pbiSpec() *service.ConfigSpec {
 	return service.NewConfigSpec().
 		Stable().
@@ -103,6 +134,14 @@ This input adds the following metadata fields to each message:
 - All message attributes
 
 You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+
+== Ack deadlines
+
+Whilst a message is being processed its ack deadline is automatically extended, up to a total of `+"`"+pbiFieldMaxExtension+"`"+`, after which the message may be redelivered. The length of each extension can be bounded with `+"`"+pbiFieldMinExtensionPeriod+"`"+` and `+"`"+pbiFieldMaxExtensionPeriod+"`"+`, where a lower maximum reduces the time it takes for messages to be redelivered when an instance fails to extend their deadlines, for example because it was terminated.
+
+== Exactly-once delivery
+
+When consuming from a subscription with exactly-once delivery enabled, `+"`"+pbiFieldExactlyOnce+"`"+` should be set so that acknowledgements are only considered complete once they are confirmed by Pub/Sub, and acknowledgements that fail, for example because the ack deadline of the message expired, are reported as errors. Messages whose acknowledgements fail are redelivered by Pub/Sub.
 `).
 		Fields(
 			service.NewStringField(pbiFieldProjectID).
@@ -127,6 +166,26 @@ You can access these metadata fields using xref:configuration:interpolation.adoc
 			service.NewIntField(pbiFieldMaxOutstandingBytes).
 				Description("The maximum number of outstanding pending messages to be consumed measured in bytes.").
 				Default(1e9), // pubsub.DefaultReceiveSettings.MaxOutstandingBytes (1G)
+			service.NewDurationField(pbiFieldMaxExtension).
+				Description("The maximum period for which the ack deadline of a message is extended whilst it is being processed. A negative duration disables extensions.").
+				Default("60m"). // pubsub.DefaultReceiveSettings.MaxExtension
+				Version("4.40.0").
+				Advanced(),
+			service.NewDurationField(pbiFieldMaxExtensionPeriod).
+				Description("The maximum duration of a single ack deadline extension, which must be between 10s and 600s. A duration of zero uses the maximum allowed by Pub/Sub.").
+				Default("0s").
+				Version("4.40.0").
+				Advanced(),
+			service.NewDurationField(pbiFieldMinExtensionPeriod).
+				Description("The minimum duration of a single ack deadline extension. A duration of zero uses the default of the client, which is 60s for subscriptions with exactly-once delivery.").
+				Default("0s").
+				Version("4.40.0").
+				Advanced(),
+			service.NewBoolField(pbiFieldExactlyOnce).
+				Description("Whether to wait for acknowledgements to be confirmed by Pub/Sub, which should be enabled for subscriptions with exactly-once delivery.").
+				Default(false).
+				Version("4.40.0").
+				Advanced(),
 			service.NewObjectField(pbiFieldCreateSub,
 				service.NewBoolField(pbiFieldCreateSubEnabled).
 					Description("Whether to configure subscription or not.").Default(false),
@@ -230,9 +289,7 @@ func (c *gcpPubSubReader) Connect(ignored context.Context) error {
 	}
 
 	sub := c.client.Subscription(c.conf.SubscriptionID)
-	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
-	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
-	sub.ReceiveSettings.Synchronous = c.conf.Sync
+	c.conf.applyReceiveSettings(&sub.ReceiveSettings)
 
 	subCtx, cancel := context.WithCancel(context.Background())
 	msgsChan := make(chan *pubsub.Message, 1)
@@ -293,14 +350,33 @@ func (c *gcpPubSubReader) Read(ctx context.Context) (*service.Message, service.A
 		part.MetaSetMut("gcp_pubsub_delivery_attempt", *gmsg.DeliveryAttempt)
 	}
 
-	return part, func(ctx context.Context, res error) error {
+	return part, pbiAckFunc(airGappedMessage{m: gmsg}, gmsg.ID, c.conf.ExactlyOnce), nil
+}
+
+// pbiAckFunc returns an ack func for a message which, when exactlyOnce is set,
+// waits for the result of the ack or nack and returns it when it fails.
+func pbiAckFunc(msg pubsubAckMessage, id string, exactlyOnce bool) service.AckFunc {
+	return func(ctx context.Context, res error) error {
+		if !exactlyOnce {
+			if res != nil {
+				msg.Nack()
+			} else {
+				msg.Ack()
+			}
+			return nil
+		}
+
 		if res != nil {
-			gmsg.Nack()
-		} else {
-			gmsg.Ack()
+			if _, err := msg.NackWithResult().Get(ctx); err != nil {
+				return fmt.Errorf("failed to nack message %v: %w", id, err)
+			}
+			return nil
+		}
+		if _, err := msg.AckWithResult().Get(ctx); err != nil {
+			return fmt.Errorf("failed to ack message %v: %w", id, err)
 		}
 		return nil
-	}, nil
+	}
 }
 
 func (c *gcpPubSubReader) Close(ctx context.Context) error {
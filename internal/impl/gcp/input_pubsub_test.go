@@ -0,0 +1,98 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPubSubInputReceiveSettings(t *testing.T) {
+	pConf, err := pbiSpec().ParseYAML(`
+project: sample-project
+subscription: sample-sub
+max_outstanding_messages: 10
+max_outstanding_bytes: 1000
+sync: true
+max_extension: 10m
+max_extension_period: 30s
+min_extension_period: 15s
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := pbiConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	settings := pubsub.DefaultReceiveSettings
+	conf.applyReceiveSettings(&settings)
+
+	assert.Equal(t, 10, settings.MaxOutstandingMessages)
+	assert.Equal(t, 1000, settings.MaxOutstandingBytes)
+	assert.True(t, settings.Synchronous)
+	assert.Equal(t, 10*time.Minute, settings.MaxExtension)
+	assert.Equal(t, 30*time.Second, settings.MaxExtensionPeriod)
+	assert.Equal(t, 15*time.Second, settings.MinExtensionPeriod)
+}
+
+func TestPubSubInputAckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	ackMsg := &mockAckMessage{}
+	ackMsg.On("Ack").Return().Once()
+	require.NoError(t, pbiAckFunc(ackMsg, "foo", false)(ctx, nil))
+
+	nackMsg := &mockAckMessage{}
+	nackMsg.On("Nack").Return().Once()
+	require.NoError(t, pbiAckFunc(nackMsg, "foo", false)(ctx, errors.New("nope")))
+
+	mock.AssertExpectationsForObjects(t, ackMsg, nackMsg)
+}
+
+func TestPubSubInputExactlyOnceAckFunc(t *testing.T) {
+	ctx := context.Background()
+
+	okRes := &mockAckResult{}
+	okRes.On("Get").Return(pubsub.AcknowledgeStatusSuccess, nil).Once()
+	okMsg := &mockAckMessage{}
+	okMsg.On("AckWithResult").Return(okRes).Once()
+	require.NoError(t, pbiAckFunc(okMsg, "foo", true)(ctx, nil))
+
+	ackRes := &mockAckResult{}
+	ackRes.On("Get").Return(pubsub.AcknowledgeStatusInvalidAckID, errors.New("simulated ack error")).Once()
+	ackMsg := &mockAckMessage{}
+	ackMsg.On("AckWithResult").Return(ackRes).Once()
+	err := pbiAckFunc(ackMsg, "foo", true)(ctx, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to ack message foo")
+	assert.Contains(t, err.Error(), "simulated ack error")
+
+	nackRes := &mockAckResult{}
+	nackRes.On("Get").Return(pubsub.AcknowledgeStatusFailedPrecondition, errors.New("simulated nack error")).Once()
+	nackMsg := &mockAckMessage{}
+	nackMsg.On("NackWithResult").Return(nackRes).Once()
+	err = pbiAckFunc(nackMsg, "bar", true)(ctx, errors.New("nope"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to nack message bar")
+	assert.Contains(t, err.Error(), "simulated nack error")
+
+	mock.AssertExpectationsForObjects(t, okRes, okMsg, ackRes, ackMsg, nackRes, nackMsg)
+}
@@ -21,7 +21,8 @@ import (
 )
 
 var (
-	_ pubsubClient = (*airGappedPubsubClient)(nil)
+	_ pubsubClient     = (*airGappedPubsubClient)(nil)
+	_ pubsubAckMessage = airGappedMessage{}
 )
 
 type pubsubClient interface {
@@ -40,6 +41,17 @@ type publishResult interface {
 	Get(ctx context.Context) (serverID string, err error)
 }
 
+type pubsubAckMessage interface {
+	Ack()
+	Nack()
+	AckWithResult() ackResult
+	NackWithResult() ackResult
+}
+
+type ackResult interface {
+	Get(ctx context.Context) (pubsub.AcknowledgeStatus, error)
+}
+
 type airGappedPubsubClient struct {
 	c *pubsub.Client
 }
@@ -74,3 +86,23 @@ func (at *airGappedTopic) EnableOrdering() {
 func (at *airGappedTopic) Stop() {
 	at.t.Stop()
 }
+
+type airGappedMessage struct {
+	m *pubsub.Message
+}
+
+func (am airGappedMessage) Ack() {
+	am.m.Ack()
+}
+
+func (am airGappedMessage) Nack() {
+	am.m.Nack()
+}
+
+func (am airGappedMessage) AckWithResult() ackResult {
+	return am.m.AckWithResult()
+}
+
+func (am airGappedMessage) NackWithResult() ackResult {
+	return am.m.NackWithResult()
+}
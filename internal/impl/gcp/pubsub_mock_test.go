@@ -75,3 +75,41 @@ func (m *mockPublishResult) Get(ctx context.Context) (string, error) {
 
 	return args.String(0), args.Error(1)
 }
+
+type mockAckMessage struct {
+	mock.Mock
+}
+
+var _ pubsubAckMessage = &mockAckMessage{}
+
+func (m *mockAckMessage) Ack() {
+	m.Called()
+}
+
+func (m *mockAckMessage) Nack() {
+	m.Called()
+}
+
+func (m *mockAckMessage) AckWithResult() ackResult {
+	args := m.Called()
+
+	return args.Get(0).(ackResult)
+}
+
+func (m *mockAckMessage) NackWithResult() ackResult {
+	args := m.Called()
+
+	return args.Get(0).(ackResult)
+}
+
+type mockAckResult struct {
+	mock.Mock
+}
+
+var _ ackResult = &mockAckResult{}
+
+func (m *mockAckResult) Get(ctx context.Context) (pubsub.AcknowledgeStatus, error) {
+	args := m.Called()
+
+	return args.Get(0).(pubsub.AcknowledgeStatus), args.Error(1)
+}
@@ -0,0 +1,384 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/Jeffail/checkpoint"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	jiFieldUnits           = "units"
+	jiFieldPriority        = "priority"
+	jiFieldStartFromOldest = "start_from_oldest"
+	jiFieldCursorCache     = "cursor_cache"
+	jiFieldCursorKey       = "cursor_key"
+	jiFieldJournalctlPath  = "journalctl_path"
+)
+
+func inputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Local").
+		Version("4.40.0").
+		Summary("Tails the systemd journal of the host by following the output of `journalctl`.").
+		Description(`
+Each journal entry is emitted as a message containing the JSON representation of the entry as produced by `+"`journalctl --output=json`"+`, which means all fields of the entry (`+"`MESSAGE`, `_SYSTEMD_UNIT`, `_PID`"+`, etc) are available to subsequent processors.
+
+The journal is read by running `+"`journalctl --follow`"+` as a subprocess, and therefore the `+"`journalctl`"+` binary must be available on the host and the user running Redpanda Connect must have permission to read the journal (usually membership of the `+"`systemd-journal`"+` group is sufficient).
+
+== Cursor persistence
+
+When a `+"`cursor_cache`"+` is configured the cursor of the latest entry to be successfully delivered is stored within it, and upon restart the journal is resumed directly after that entry. When the cache is empty or not configured the input starts from either the newest or oldest entry of the journal depending on the field `+"`start_from_oldest`"+`.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+- journald_cursor
+- journald_unit
+- journald_priority
+- journald_hostname
+- journald_realtime_timestamp
+
+You can access these metadata fields using xref:configuration:interpolation.adoc#bloblang-queries[function interpolation].
+`).
+		Fields(
+			service.NewStringListField(jiFieldUnits).
+				Description("An optional list of systemd units to read entries from. When empty all entries of the journal are consumed.").
+				Example([]string{"nginx.service", "sshd.service"}).
+				Default([]string{}),
+			service.NewStringField(jiFieldPriority).
+				Description("An optional maximum priority of entries to consume, either as a syslog level name or a number from 0 (emerg) to 7 (debug). Entries of a lower priority (higher number) are ignored.").
+				Example("warning").
+				Example("3").
+				Optional(),
+			service.NewBoolField(jiFieldStartFromOldest).
+				Description("Whether to start from the oldest entry of the journal when there is no cursor to resume from. When `false` only entries written after the input connects are consumed.").
+				Default(false),
+			service.NewStringField(jiFieldCursorCache).
+				Description("An optional xref:components:caches/about.adoc[cache resource] used for storing the cursor of the latest entry that has been successfully delivered, this allows Redpanda Connect to continue from that entry upon restart.").
+				Optional(),
+			service.NewStringField(jiFieldCursorKey).
+				Description("The key identifier used when storing the cursor within the `cursor_cache`.").
+				Default("journald_cursor").
+				Advanced(),
+			service.NewStringField(jiFieldJournalctlPath).
+				Description("The path of the `journalctl` binary to execute.").
+				Default("journalctl").
+				Advanced(),
+			service.NewAutoRetryNacksToggleField(),
+		).
+		Example("Tail a Service", "Consume the entries of the nginx service, resuming from the last delivered entry upon restarts, and extract the log line from each entry.", `
+input:
+  journald:
+    units: [ nginx.service ]
+    cursor_cache: cursors
+
+pipeline:
+  processors:
+    - mapping: |
+        root.message = this.MESSAGE
+        root.host = meta("journald_hostname")
+
+cache_resources:
+  - label: cursors
+    file:
+      directory: /var/lib/redpanda-connect/cursors
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"journald", inputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			reader, err := newJournaldInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, reader)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type journaldEntry struct {
+	raw      []byte
+	cursor   string
+	unit     string
+	prio     string
+	host     string
+	realtime string
+}
+
+func parseJournaldEntry(line []byte) (*journaldEntry, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse journal entry: %w", err)
+	}
+	getStr := func(k string) string {
+		s, _ := fields[k].(string)
+		return s
+	}
+	e := &journaldEntry{
+		raw:      line,
+		cursor:   getStr("__CURSOR"),
+		unit:     getStr("_SYSTEMD_UNIT"),
+		prio:     getStr("PRIORITY"),
+		host:     getStr("_HOSTNAME"),
+		realtime: getStr("__REALTIME_TIMESTAMP"),
+	}
+	if e.cursor == "" {
+		return nil, errors.New("journal entry is missing a cursor")
+	}
+	return e, nil
+}
+
+func (e *journaldEntry) toMessage() *service.Message {
+	msg := service.NewMessage(e.raw)
+	msg.MetaSetMut("journald_cursor", e.cursor)
+	msg.MetaSetMut("journald_unit", e.unit)
+	msg.MetaSetMut("journald_priority", e.prio)
+	msg.MetaSetMut("journald_hostname", e.host)
+	msg.MetaSetMut("journald_realtime_timestamp", e.realtime)
+	return msg
+}
+
+//------------------------------------------------------------------------------
+
+type journaldInput struct {
+	units           []string
+	priority        string
+	startFromOldest bool
+	cursorCache     string
+	cursorKey       string
+	journalctlPath  string
+
+	mgr          *service.Resources
+	log          *service.Logger
+	checkpointer *checkpoint.Capped[string]
+
+	cmdMut  sync.Mutex
+	cmd     *exec.Cmd
+	entries chan *journaldEntry
+	errs    chan error
+}
+
+func newJournaldInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*journaldInput, error) {
+	j := &journaldInput{
+		mgr:          mgr,
+		log:          mgr.Logger(),
+		checkpointer: checkpoint.NewCapped[string](1024),
+	}
+
+	var err error
+	if j.units, err = conf.FieldStringList(jiFieldUnits); err != nil {
+		return nil, err
+	}
+	if conf.Contains(jiFieldPriority) {
+		if j.priority, err = conf.FieldString(jiFieldPriority); err != nil {
+			return nil, err
+		}
+	}
+	if j.startFromOldest, err = conf.FieldBool(jiFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+	if conf.Contains(jiFieldCursorCache) {
+		if j.cursorCache, err = conf.FieldString(jiFieldCursorCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(j.cursorCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", j.cursorCache)
+		}
+	}
+	if j.cursorKey, err = conf.FieldString(jiFieldCursorKey); err != nil {
+		return nil, err
+	}
+	if j.journalctlPath, err = conf.FieldString(jiFieldJournalctlPath); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// journalctlArgs returns the arguments to execute journalctl with, resuming
+// after the provided cursor when it is non-empty.
+func (j *journaldInput) journalctlArgs(cursor string) []string {
+	args := []string{"--follow", "--output=json", "--no-pager", "--quiet"}
+	switch {
+	case cursor != "":
+		args = append(args, "--after-cursor="+cursor)
+	case j.startFromOldest:
+		args = append(args, "--lines=all")
+	default:
+		args = append(args, "--lines=0")
+	}
+	for _, u := range j.units {
+		args = append(args, "--unit="+u)
+	}
+	if j.priority != "" {
+		args = append(args, "--priority="+j.priority)
+	}
+	return args
+}
+
+func (j *journaldInput) readCursor(ctx context.Context) (string, error) {
+	if j.cursorCache == "" {
+		return "", nil
+	}
+	var cursor []byte
+	var cErr error
+	if err := j.mgr.AccessCache(ctx, j.cursorCache, func(c service.Cache) {
+		if cursor, cErr = c.Get(ctx, j.cursorKey); errors.Is(cErr, service.ErrKeyNotFound) {
+			cErr = nil
+		}
+	}); err != nil {
+		return "", err
+	}
+	return string(cursor), cErr
+}
+
+func (j *journaldInput) Connect(ctx context.Context) error {
+	j.cmdMut.Lock()
+	defer j.cmdMut.Unlock()
+	if j.cmd != nil {
+		return nil
+	}
+
+	cursor, err := j.readCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain latest delivered cursor: %w", err)
+	}
+
+	cmd := exec.Command(j.journalctlPath, j.journalctlArgs(cursor)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %w", err)
+	}
+
+	entries, errs := make(chan *journaldEntry), make(chan error, 1)
+	go func() {
+		defer close(entries)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			e, err := parseJournaldEntry(append([]byte(nil), line...))
+			if err != nil {
+				j.log.Errorf("Skipping journal entry: %v", err)
+				continue
+			}
+			entries <- e
+		}
+		err := scanner.Err()
+		if wErr := cmd.Wait(); err == nil {
+			err = wErr
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		errs <- err
+	}()
+
+	j.cmd, j.entries, j.errs = cmd, entries, errs
+	return nil
+}
+
+func (j *journaldInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	j.cmdMut.Lock()
+	entries, errs := j.entries, j.errs
+	j.cmdMut.Unlock()
+	if entries == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var e *journaldEntry
+	var open bool
+	select {
+	case e, open = <-entries:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	if !open {
+		err := <-errs
+		j.log.Errorf("Journalctl exited: %v", err)
+
+		j.cmdMut.Lock()
+		j.cmd, j.entries, j.errs = nil, nil, nil
+		j.cmdMut.Unlock()
+		return nil, nil, service.ErrNotConnected
+	}
+
+	if j.cursorCache == "" {
+		return e.toMessage(), func(context.Context, error) error { return nil }, nil
+	}
+
+	release, err := j.checkpointer.Track(ctx, e.cursor, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.toMessage(), func(ctx context.Context, err error) error {
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		var setErr error
+		if err := j.mgr.AccessCache(ctx, j.cursorCache, func(c service.Cache) {
+			setErr = c.Set(ctx, j.cursorKey, []byte(*highest), nil)
+		}); err != nil {
+			return err
+		}
+		return setErr
+	}, nil
+}
+
+func (j *journaldInput) Close(ctx context.Context) error {
+	j.cmdMut.Lock()
+	cmd, entries := j.cmd, j.entries
+	j.cmd, j.entries, j.errs = nil, nil, nil
+	j.cmdMut.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+	// Drain any pending entries so that the reading goroutine can exit.
+	go func() {
+		for range entries {
+		}
+	}()
+	return nil
+}
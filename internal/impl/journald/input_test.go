@@ -0,0 +1,115 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestJournaldArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     string
+		cursor   string
+		expected []string
+	}{
+		{
+			name: "defaults",
+			conf: `{}`,
+			expected: []string{
+				"--follow", "--output=json", "--no-pager", "--quiet", "--lines=0",
+			},
+		},
+		{
+			name: "from oldest with filters",
+			conf: `
+units: [ foo.service, bar.service ]
+priority: warning
+start_from_oldest: true
+`,
+			expected: []string{
+				"--follow", "--output=json", "--no-pager", "--quiet", "--lines=all",
+				"--unit=foo.service", "--unit=bar.service", "--priority=warning",
+			},
+		},
+		{
+			name: "resume from cursor",
+			conf: `
+start_from_oldest: true
+`,
+			cursor: "s=abc;i=1",
+			expected: []string{
+				"--follow", "--output=json", "--no-pager", "--quiet", "--after-cursor=s=abc;i=1",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := inputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			j, err := newJournaldInputFromConfig(pConf, service.MockResources())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, j.journalctlArgs(test.cursor))
+		})
+	}
+}
+
+func TestJournaldParseEntry(t *testing.T) {
+	line := []byte(`{"__CURSOR":"s=abc;i=1","__REALTIME_TIMESTAMP":"1700000000000000","PRIORITY":"6","_HOSTNAME":"host1","_SYSTEMD_UNIT":"foo.service","MESSAGE":"hello world"}`)
+
+	e, err := parseJournaldEntry(line)
+	require.NoError(t, err)
+
+	msg := e.toMessage()
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, string(line), string(b))
+
+	for k, v := range map[string]string{
+		"journald_cursor":             "s=abc;i=1",
+		"journald_unit":               "foo.service",
+		"journald_priority":           "6",
+		"journald_hostname":           "host1",
+		"journald_realtime_timestamp": "1700000000000000",
+	} {
+		actual, exists := msg.MetaGet(k)
+		assert.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+
+	_, err = parseJournaldEntry([]byte(`{"MESSAGE":"no cursor"}`))
+	require.Error(t, err)
+
+	_, err = parseJournaldEntry([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestJournaldMissingCache(t *testing.T) {
+	pConf, err := inputConfig().ParseYAML(`cursor_cache: nope`, nil)
+	require.NoError(t, err)
+
+	_, err = newJournaldInputFromConfig(pConf, service.MockResources())
+	require.Error(t, err)
+}
@@ -205,6 +205,91 @@ func FranzConsumerOptsFromConfig(conf *service.ParsedConfig) ([]kgo.Opt, error)
 	return details.FranzOpts(), nil
 }
 
+const (
+	// Consumer group membership fields
+	kfrFieldInstanceID     = "instance_id"
+	kfrFieldGroupBalancers = "group_balancers"
+	kfrFieldSessionTimeout = "session_timeout"
+)
+
+// franzGroupMembershipFields returns fields for customising how a consumer
+// participates in a consumer group, these are only applied when a consumer
+// group is configured.
+func franzGroupMembershipFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField(kfrFieldInstanceID).
+			Description("An optional static group instance ID (the equivalent to the Java group.instance.id setting), which enables static membership of the consumer group. Static members that restart within the `" + kfrFieldSessionTimeout + "` keep their partition assignments, which prevents rebalances during rolling deployments. Each consumer within the group must be given a unique instance ID, which is usually achieved with environment variable interpolation.").
+			Example("${HOSTNAME}").
+			Optional().
+			Advanced(),
+		service.NewStringListField(kfrFieldGroupBalancers).
+			Description("The list of group balancers, in order of preference, advertised by this consumer when joining a consumer group. The `cooperative_sticky` balancer performs incremental rebalances where only the partitions that move between consumers are revoked. Listing multiple balancers allows for a rolling migration between strategies. Options are `cooperative_sticky`, `sticky`, `range` and `round_robin`.").
+			Default([]any{"cooperative_sticky"}).
+			Example([]string{"range"}).
+			Example([]string{"cooperative_sticky", "range"}).
+			LintRule(`root = this.filter(b -> !["cooperative_sticky","sticky","range","round_robin"].contains(b)).map_each(b -> "unrecognised group balancer: %v".format(b))`).
+			Advanced(),
+		service.NewDurationField(kfrFieldSessionTimeout).
+			Description("The period of time after which a consumer that stops heartbeating is removed from the group, triggering a rebalance. When using static membership this should be longer than the time it takes for an instance to restart.").
+			Default("45s").
+			Advanced(),
+	}
+}
+
+func franzGroupBalancer(name string) (kgo.GroupBalancer, error) {
+	switch name {
+	case "cooperative_sticky":
+		return kgo.CooperativeStickyBalancer(), nil
+	case "sticky":
+		return kgo.StickyBalancer(), nil
+	case "range":
+		return kgo.RangeBalancer(), nil
+	case "round_robin":
+		return kgo.RoundRobinBalancer(), nil
+	}
+	return nil, fmt.Errorf("unrecognised group balancer: %v", name)
+}
+
+// franzGroupMembershipOptsFromConfig returns a slice of franz-go client opts
+// that customise consumer group membership from a parsed config.
+func franzGroupMembershipOptsFromConfig(conf *service.ParsedConfig) ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+
+	if conf.Contains(kfrFieldInstanceID) {
+		instanceID, err := conf.FieldString(kfrFieldInstanceID)
+		if err != nil {
+			return nil, err
+		}
+		if instanceID != "" {
+			opts = append(opts, kgo.InstanceID(instanceID))
+		}
+	}
+
+	balancerNames, err := conf.FieldStringList(kfrFieldGroupBalancers)
+	if err != nil {
+		return nil, err
+	}
+	if len(balancerNames) > 0 {
+		balancers := make([]kgo.GroupBalancer, 0, len(balancerNames))
+		for _, name := range balancerNames {
+			b, err := franzGroupBalancer(name)
+			if err != nil {
+				return nil, err
+			}
+			balancers = append(balancers, b)
+		}
+		opts = append(opts, kgo.Balancers(balancers...))
+	}
+
+	sessionTimeout, err := conf.FieldDuration(kfrFieldSessionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, kgo.SessionTimeout(sessionTimeout))
+
+	return opts, nil
+}
+
 // FranzRecordToMessageV0 converts a record into a service.Message, adding
 // metadata and other relevant information.
 func FranzRecordToMessageV0(record *kgo.Record, multiHeader bool) *service.Message {
@@ -40,7 +40,7 @@ const (
 // FranzReaderOrderedConfigFields returns config fields for customising the
 // behaviour of kafka reader with strict ordering using the franz-go library.
 func FranzReaderOrderedConfigFields() []*service.ConfigField {
-	return []*service.ConfigField{
+	return append([]*service.ConfigField{
 		service.NewStringField(kroFieldConsumerGroup).
 			Description("An optional consumer group to consume as. When specified the partitions of specified topics are automatically distributed across consumers sharing a consumer group, and partition offsets are automatically committed and resumed under this name. Consumer groups are not supported when specifying explicit partitions to consume from in the `topics` field.").
 			Optional(),
@@ -52,7 +52,7 @@ func FranzReaderOrderedConfigFields() []*service.ConfigField {
 			Description("A buffer size (in bytes) for each consumed partition, allowing records to be queued internally before flushing. Increasing this may improve throughput at the cost of higher memory utilisation. Note that each buffer can grow slightly beyond this value.").
 			Default("1MB").
 			Advanced(),
-	}
+	}, franzGroupMembershipFields()...)
 }
 
 //------------------------------------------------------------------------------
@@ -64,6 +64,7 @@ type FranzReaderOrdered struct {
 	partState *partitionState
 
 	consumerGroup string
+	groupOpts     []kgo.Opt
 	commitPeriod  time.Duration
 	cacheLimit    uint64
 
@@ -93,6 +94,12 @@ func NewFranzReaderOrderedFromConfig(conf *service.ParsedConfig, res *service.Re
 	f.consumerGroup, _ = conf.FieldString(kroFieldConsumerGroup)
 
 	var err error
+	if f.consumerGroup != "" {
+		if f.groupOpts, err = franzGroupMembershipOptsFromConfig(conf); err != nil {
+			return nil, err
+		}
+	}
+
 	if f.cacheLimit, err = bytesFromStrField(kroFieldPartitionBuffer, conf); err != nil {
 		return nil, err
 	}
@@ -374,6 +381,7 @@ func (f *FranzReaderOrdered) Connect(ctx context.Context) error {
 			kgo.AutoCommitInterval(f.commitPeriod),
 			kgo.WithLogger(&KGoLogger{f.log}),
 		)
+		clientOpts = append(clientOpts, f.groupOpts...)
 	}
 
 	if cl, err = kgo.NewClient(clientOpts...); err != nil {
@@ -0,0 +1,83 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestFranzGroupMembershipOpts(t *testing.T) {
+	spec := service.NewConfigSpec().Fields(franzGroupMembershipFields()...)
+
+	testCases := []struct {
+		name        string
+		conf        string
+		optsLen     int
+		errContains string
+	}{
+		{
+			name:    "defaults",
+			conf:    `{}`,
+			optsLen: 2,
+		},
+		{
+			name: "static membership",
+			conf: `
+instance_id: foo-0
+group_balancers: [ cooperative_sticky, range ]
+session_timeout: 2m
+`,
+			optsLen: 3,
+		},
+		{
+			name: "no balancers",
+			conf: `
+group_balancers: []
+`,
+			optsLen: 1,
+		},
+		{
+			name: "bad balancer",
+			conf: `
+group_balancers: [ nope ]
+`,
+			errContains: "unrecognised group balancer: nope",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var opts []kgo.Opt
+			pConf, err := spec.ParseYAML(test.conf, nil)
+			if err == nil {
+				opts, err = franzGroupMembershipOptsFromConfig(pConf)
+			}
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, opts, test.optsLen)
+		})
+	}
+}
@@ -44,7 +44,7 @@ const (
 // a given partition, but still ensures that offsets are only committed when
 // safe.
 func FranzReaderUnorderedConfigFields() []*service.ConfigField {
-	return []*service.ConfigField{
+	return append([]*service.ConfigField{
 		service.NewStringField(kruFieldConsumerGroup).
 			Description("An optional consumer group to consume as. When specified the partitions of specified topics are automatically distributed across consumers sharing a consumer group, and partition offsets are automatically committed and resumed under this name. Consumer groups are not supported when specifying explicit partitions to consume from in the `topics` field.").
 			Optional(),
@@ -63,7 +63,7 @@ func FranzReaderUnorderedConfigFields() []*service.ConfigField {
 		service.NewBatchPolicyField(kruFieldBatching).
 			Description("Allows you to configure a xref:configuration:batching.adoc[batching policy] that applies to individual topic partitions in order to batch messages together before flushing them for processing. Batching can be beneficial for performance as well as useful for windowed processing, and doing so this way preserves the ordering of topic partitions.").
 			Advanced(),
-	}
+	}, franzGroupMembershipFields()...)
 }
 
 //------------------------------------------------------------------------------
@@ -81,6 +81,7 @@ type FranzReaderUnordered struct {
 	clientOpts []kgo.Opt
 
 	consumerGroup   string
+	groupOpts       []kgo.Opt
 	checkpointLimit int
 	commitPeriod    time.Duration
 	multiHeader     bool
@@ -114,6 +115,12 @@ func NewFranzReaderUnorderedFromConfig(conf *service.ParsedConfig, res *service.
 	f.consumerGroup, _ = conf.FieldString(kruFieldConsumerGroup)
 
 	var err error
+	if f.consumerGroup != "" {
+		if f.groupOpts, err = franzGroupMembershipOptsFromConfig(conf); err != nil {
+			return nil, err
+		}
+	}
+
 	if f.checkpointLimit, err = conf.FieldInt(kruFieldCheckpointLimit); err != nil {
 		return nil, err
 	}
@@ -484,6 +491,7 @@ func (f *FranzReaderUnordered) Connect(ctx context.Context) error {
 			kgo.AutoCommitInterval(f.commitPeriod),
 			kgo.WithLogger(&KGoLogger{f.log}),
 		)
+		clientOpts = append(clientOpts, f.groupOpts...)
 	}
 
 	var err error
@@ -34,6 +34,9 @@ const (
 	// Producer fields
 	kfwFieldPartitioner         = "partitioner"
 	kfwFieldIdempotentWrite     = "idempotent_write"
+	kfwFieldAcks                = "acks"
+	kfwFieldMaxInFlightRequests = "max_in_flight_requests_per_broker"
+	kfwFieldDeliveryTimeout     = "delivery_timeout"
 	kfwFieldCompression         = "compression"
 	kfwFieldTimeout             = "timeout"
 	kfwFieldMaxMessageBytes     = "max_message_bytes"
@@ -56,6 +59,23 @@ func FranzProducerFields() []*service.ConfigField {
 			Description("Enable the idempotent write producer option. This requires the `IDEMPOTENT_WRITE` permission on `CLUSTER` and can be disabled if this permission is not available.").
 			Default(true).
 			Advanced(),
+		service.NewStringAnnotatedEnumField(kfwFieldAcks, map[string]string{
+			"all":    "Wait for all in-sync replicas to acknowledge each write, the equivalent to `acks=all`. This is required when `idempotent_write` is enabled.",
+			"leader": "Wait only for the partition leader to acknowledge each write, the equivalent to `acks=1`.",
+			"none":   "Do not wait for any acknowledgement of writes, the equivalent to `acks=0`. Records can be lost without any error being reported.",
+		}).
+			Description("The number of acknowledgements required from brokers before a write is considered successful.").
+			Default("all").
+			Advanced(),
+		service.NewIntField(kfwFieldMaxInFlightRequests).
+			Description("The maximum number of produce requests that can be in flight to a single broker at any given time, the equivalent to the Java max.in.flight.requests.per.connection setting. This field only applies when `idempotent_write` is disabled, as the number of requests in flight is managed by the client when writes are idempotent, and increasing it allows records within a partition to be reordered when requests are retried.").
+			Default(1).
+			Advanced(),
+		service.NewDurationField(kfwFieldDeliveryTimeout).
+			Description("An optional upper bound on the time a record can take to be delivered, including all retries, the equivalent to the Java delivery.timeout.ms setting. Records that exceed this limit are failed, and the messages are reattempted according to the input. When not set records are retried until delivered or until the `timeout` of the write is reached.").
+			Example("2m").
+			Optional().
+			Advanced(),
 		service.NewStringEnumField(kfwFieldCompression, "lz4", "snappy", "gzip", "none", "zstd").
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
@@ -169,6 +189,47 @@ func FranzProducerOptsFromConfig(conf *service.ParsedConfig) ([]kgo.Opt, error)
 		opts = append(opts, kgo.DisableIdempotentWrite())
 	}
 
+	acksStr, err := conf.FieldString(kfwFieldAcks)
+	if err != nil {
+		return nil, err
+	}
+	switch acksStr {
+	case "all":
+		opts = append(opts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	case "leader":
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()))
+	case "none":
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()))
+	default:
+		return nil, fmt.Errorf("unknown acks value: %v", acksStr)
+	}
+	if idempotentWrite && acksStr != "all" {
+		return nil, fmt.Errorf("acks must be set to all when idempotent_write is enabled, got %v", acksStr)
+	}
+
+	maxInFlightRequests, err := conf.FieldInt(kfwFieldMaxInFlightRequests)
+	if err != nil {
+		return nil, err
+	}
+	if maxInFlightRequests < 1 {
+		return nil, fmt.Errorf("invalid %v, must be at least 1", kfwFieldMaxInFlightRequests)
+	}
+	if idempotentWrite {
+		if maxInFlightRequests != 1 {
+			return nil, fmt.Errorf("invalid %v, must be 1 when idempotent_write is enabled", kfwFieldMaxInFlightRequests)
+		}
+	} else {
+		opts = append(opts, kgo.MaxProduceRequestsInflightPerBroker(maxInFlightRequests))
+	}
+
+	if conf.Contains(kfwFieldDeliveryTimeout) {
+		deliveryTimeout, err := conf.FieldDuration(kfwFieldDeliveryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.RecordDeliveryTimeout(deliveryTimeout))
+	}
+
 	timeout, err := conf.FieldDuration(kfwFieldTimeout)
 	if err != nil {
 		return nil, err
@@ -82,3 +82,71 @@ kafka_franz:
 		})
 	}
 }
+
+func TestKafkaFranzOutputProducerOpts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		conf        string
+		errContains string
+	}{
+		{
+			name: "defaults",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topic: foo
+`,
+		},
+		{
+			name: "idempotent with delivery timeout",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topic: foo
+delivery_timeout: 2m
+`,
+		},
+		{
+			name: "idempotent with more requests in flight",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topic: foo
+max_in_flight_requests_per_broker: 5
+`,
+			errContains: "must be 1 when idempotent_write is enabled",
+		},
+		{
+			name: "idempotent with leader acks",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topic: foo
+acks: leader
+`,
+			errContains: "acks must be set to all when idempotent_write is enabled",
+		},
+		{
+			name: "non idempotent with leader acks",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topic: foo
+idempotent_write: false
+acks: leader
+max_in_flight_requests_per_broker: 10
+`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := franzKafkaOutputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = FranzProducerOptsFromConfig(pConf)
+			if test.errContains == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/shutdown"
+	"github.com/gofrs/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	krrFieldRequestTopic      = "request_topic"
+	krrFieldKey               = "key"
+	krrFieldReplyTopic        = "reply_topic"
+	krrFieldCorrelationHeader = "correlation_header"
+	krrFieldReplyTopicHeader  = "reply_topic_header"
+	krrFieldMetadata          = "metadata"
+	krrFieldTimeout           = "timeout"
+)
+
+func kafkaRequestReplyProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.40.0").
+		Summary("Publishes each message as a request to a Kafka topic and replaces it with the reply consumed from a reply topic.").
+		Description(`
+Each message is written to the `+"`"+krrFieldRequestTopic+"`"+` with a unique correlation ID and the name of the `+"`"+krrFieldReplyTopic+"`"+` set as record headers. The responding service is expected to write its reply to the reply topic with the same correlation ID header, at which point the contents of the message are replaced with the value of the reply and the headers of the reply are added as metadata.
+
+Replies are consumed from the end of the reply topic without a consumer group, starting from the moment the processor is created, and replies with an unknown correlation ID are ignored. This means that multiple instances can share a reply topic, although each instance consumes all replies, and therefore it is more efficient to give each instance its own reply topic.
+
+If a reply is not received within the `+"`"+krrFieldTimeout+"`"+` the message is flagged as failed, and can be handled with xref:configuration:error_handling.adoc[error handling patterns]. When used in combination with the `+"`http_server`"+` input and the `+"`sync_response`"+` output this processor allows HTTP requests to be served by services that communicate over Kafka.
+
+== Metadata
+
+This processor adds the following metadata fields to each message:
+
+- kafka_reply_topic
+- kafka_reply_partition
+- kafka_reply_offset
+- All reply record headers
+`).
+		Fields(slices.Concat(
+			FranzConnectionFields(),
+			[]*service.ConfigField{
+				service.NewInterpolatedStringField(krrFieldRequestTopic).
+					Description("The topic to write requests to."),
+				service.NewInterpolatedStringField(krrFieldKey).
+					Description("An optional key to populate for each request.").
+					Optional(),
+				service.NewStringField(krrFieldReplyTopic).
+					Description("The topic to consume replies from."),
+				service.NewStringField(krrFieldCorrelationHeader).
+					Description("The name of the header that carries the correlation ID of requests and replies.").
+					Default("correlation_id").
+					Advanced(),
+				service.NewStringField(krrFieldReplyTopicHeader).
+					Description("The name of the header that carries the reply topic of requests.").
+					Default("reply_topic").
+					Advanced(),
+				service.NewMetadataFilterField(krrFieldMetadata).
+					Description("Determine which (if any) metadata values should be added to requests as headers.").
+					Optional(),
+				service.NewDurationField(krrFieldTimeout).
+					Description("The maximum period of time to wait for a reply.").
+					Default("10s"),
+			},
+		)...).
+		Example("RPC over HTTP", "Serve HTTP requests by forwarding them to a service that consumes from a Kafka topic and writes replies to another.", `
+input:
+  http_server:
+    path: /lookup
+
+pipeline:
+  processors:
+    - kafka_request_reply:
+        seed_brokers: [ localhost:9092 ]
+        request_topic: lookup_requests
+        reply_topic: lookup_replies
+        timeout: 5s
+
+output:
+  sync_response: {}
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"kafka_request_reply", kafkaRequestReplyProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newKafkaRequestReplyProcessorFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kafkaRequestReplyProcessor struct {
+	requestTopic      *service.InterpolatedString
+	key               *service.InterpolatedString
+	replyTopic        string
+	correlationHeader string
+	replyTopicHeader  string
+	metaFilter        *service.MetadataFilter
+	timeout           time.Duration
+
+	log      *service.Logger
+	shutSig  *shutdown.Signaller
+	producer *kgo.Client
+	consumer *kgo.Client
+
+	pendingMut sync.Mutex
+	pending    map[string]chan *kgo.Record
+}
+
+func newKafkaRequestReplyProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*kafkaRequestReplyProcessor, error) {
+	p := &kafkaRequestReplyProcessor{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+		pending: map[string]chan *kgo.Record{},
+	}
+
+	var err error
+	if p.requestTopic, err = conf.FieldInterpolatedString(krrFieldRequestTopic); err != nil {
+		return nil, err
+	}
+	if conf.Contains(krrFieldKey) {
+		if p.key, err = conf.FieldInterpolatedString(krrFieldKey); err != nil {
+			return nil, err
+		}
+	}
+	if p.replyTopic, err = conf.FieldString(krrFieldReplyTopic); err != nil {
+		return nil, err
+	}
+	if p.correlationHeader, err = conf.FieldString(krrFieldCorrelationHeader); err != nil {
+		return nil, err
+	}
+	if p.replyTopicHeader, err = conf.FieldString(krrFieldReplyTopicHeader); err != nil {
+		return nil, err
+	}
+	if conf.Contains(krrFieldMetadata) {
+		if p.metaFilter, err = conf.FieldMetadataFilter(krrFieldMetadata); err != nil {
+			return nil, err
+		}
+	}
+	if p.timeout, err = conf.FieldDuration(krrFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	connOpts, err := FranzConnectionOptsFromConfig(conf, mgr.Logger())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.producer, err = kgo.NewClient(connOpts...); err != nil {
+		return nil, err
+	}
+
+	// Replies are consumed from the moment the processor is created, which
+	// avoids missing replies to requests sent before the end offsets of the
+	// reply topic are first listed.
+	consumerOpts := append(slices.Clone(connOpts),
+		kgo.ConsumeTopics(p.replyTopic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AfterMilli(time.Now().UnixMilli())),
+	)
+	if p.consumer, err = kgo.NewClient(consumerOpts...); err != nil {
+		p.producer.Close()
+		return nil, err
+	}
+
+	go p.consumeReplies()
+	return p, nil
+}
+
+func (p *kafkaRequestReplyProcessor) consumeReplies() {
+	defer p.shutSig.TriggerHasStopped()
+
+	ctx, done := p.shutSig.SoftStopCtx(context.Background())
+	defer done()
+
+	for {
+		fetches := p.consumer.PollFetches(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			p.log.Errorf("Failed to consume replies from topic %v partition %v: %v", topic, partition, err)
+		})
+		fetches.EachRecord(p.deliverReply)
+	}
+}
+
+func (p *kafkaRequestReplyProcessor) deliverReply(r *kgo.Record) {
+	var id string
+	for _, h := range r.Headers {
+		if h.Key == p.correlationHeader {
+			id = string(h.Value)
+			break
+		}
+	}
+	if id == "" {
+		return
+	}
+
+	p.pendingMut.Lock()
+	replyChan, exists := p.pending[id]
+	delete(p.pending, id)
+	p.pendingMut.Unlock()
+
+	if exists {
+		replyChan <- r
+	}
+}
+
+func (p *kafkaRequestReplyProcessor) register(id string) chan *kgo.Record {
+	replyChan := make(chan *kgo.Record, 1)
+	p.pendingMut.Lock()
+	p.pending[id] = replyChan
+	p.pendingMut.Unlock()
+	return replyChan
+}
+
+func (p *kafkaRequestReplyProcessor) deregister(id string) {
+	p.pendingMut.Lock()
+	delete(p.pending, id)
+	p.pendingMut.Unlock()
+}
+
+func (p *kafkaRequestReplyProcessor) request(ctx context.Context, msg *service.Message, topic string, key []byte) (*kgo.Record, error) {
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	id := u4.String()
+
+	record := &kgo.Record{
+		Topic: topic,
+		Key:   key,
+		Headers: []kgo.RecordHeader{
+			{Key: p.correlationHeader, Value: []byte(id)},
+			{Key: p.replyTopicHeader, Value: []byte(p.replyTopic)},
+		},
+	}
+	if record.Value, err = msg.AsBytes(); err != nil {
+		return nil, err
+	}
+	_ = p.metaFilter.Walk(msg, func(key, value string) error {
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   key,
+			Value: []byte(value),
+		})
+		return nil
+	})
+
+	replyChan := p.register(id)
+	defer p.deregister(id)
+
+	if err := p.producer.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-replyChan:
+		return reply, nil
+	case <-timer.C:
+		return nil, errors.New("timed out waiting for reply")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *kafkaRequestReplyProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	topicExec := batch.InterpolationExecutor(p.requestTopic)
+	var keyExec *service.MessageBatchInterpolationExecutor
+	if p.key != nil {
+		keyExec = batch.InterpolationExecutor(p.key)
+	}
+
+	var wg sync.WaitGroup
+	for i, msg := range batch {
+		topic, err := topicExec.TryString(i)
+		if err != nil {
+			msg.SetError(fmt.Errorf("request topic interpolation error: %w", err))
+			continue
+		}
+		var key []byte
+		if keyExec != nil {
+			if key, err = keyExec.TryBytes(i); err != nil {
+				msg.SetError(fmt.Errorf("key interpolation error: %w", err))
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			reply, err := p.request(ctx, msg, topic, key)
+			if err != nil {
+				msg.SetError(err)
+				return
+			}
+
+			msg.SetBytes(reply.Value)
+			msg.MetaSetMut("kafka_reply_topic", reply.Topic)
+			msg.MetaSetMut("kafka_reply_partition", int(reply.Partition))
+			msg.MetaSetMut("kafka_reply_offset", int(reply.Offset))
+			for _, h := range reply.Headers {
+				msg.MetaSetMut(h.Key, string(h.Value))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return []service.MessageBatch{batch}, nil
+}
+
+func (p *kafkaRequestReplyProcessor) Close(ctx context.Context) error {
+	p.shutSig.TriggerSoftStop()
+	select {
+	case <-p.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.consumer.Close()
+	p.producer.Close()
+	return nil
+}
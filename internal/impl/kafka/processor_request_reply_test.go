@@ -0,0 +1,60 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestKafkaRequestReplyDeliver(t *testing.T) {
+	p := &kafkaRequestReplyProcessor{
+		correlationHeader: "correlation_id",
+		pending:           map[string]chan *kgo.Record{},
+	}
+
+	fooChan := p.register("foo")
+	barChan := p.register("bar")
+
+	p.deliverReply(&kgo.Record{Value: []byte("no header")})
+	p.deliverReply(&kgo.Record{
+		Value:   []byte("unknown"),
+		Headers: []kgo.RecordHeader{{Key: "correlation_id", Value: []byte("baz")}},
+	})
+	p.deliverReply(&kgo.Record{
+		Value:   []byte("foo reply"),
+		Headers: []kgo.RecordHeader{{Key: "correlation_id", Value: []byte("foo")}},
+	})
+
+	// Duplicate replies are ignored once the first has been delivered.
+	p.deliverReply(&kgo.Record{
+		Value:   []byte("foo reply again"),
+		Headers: []kgo.RecordHeader{{Key: "correlation_id", Value: []byte("foo")}},
+	})
+
+	select {
+	case r := <-fooChan:
+		assert.Equal(t, "foo reply", string(r.Value))
+	default:
+		t.Fatal("expected a reply for foo")
+	}
+	assert.Empty(t, barChan)
+
+	p.deregister("bar")
+	require.Empty(t, p.pending)
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	osFieldBucket         = "bucket"
+	osiFieldIgnoreDeletes = "ignore_deletes"
+	osiFieldUpdatesOnly   = "updates_only"
+)
+
+func natsObjectStoreInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.40.0").
+		Summary("Watches for changes to objects in a NATS object store bucket.").
+		Description(`
+A message is created for each object that is added to, updated within or deleted from the bucket, where the contents of the message are the contents of the object. Messages of deleted objects are empty. By default the current objects of the bucket are consumed first, followed by any changes, which can be disabled with the field `+"`"+osiFieldUpdatesOnly+"`"+`. When the contents of an object cannot be read the watch is restarted, consuming the current objects of the bucket again unless `+"`"+osiFieldUpdatesOnly+"`"+` is set, in which case the failed change is skipped.
+
+The user defined metadata of each object is also added to each message.
+
+== Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"``` text"+`
+- nats_object_store_bucket
+- nats_object_store_name
+- nats_object_store_nuid
+- nats_object_store_size
+- nats_object_store_digest
+- nats_object_store_mod_time
+- nats_object_store_deleted
+`+"```"+`
+
+`+connectionNameDescription()+authDescription()).
+		Fields(connectionHeadFields()...).
+		Fields(
+			service.NewStringField(osFieldBucket).
+				Description("The name of the object store bucket.").
+				Example("my_object_bucket"),
+			service.NewAutoRetryNacksToggleField(),
+			service.NewBoolField(osiFieldIgnoreDeletes).
+				Description("Do not send messages for deleted objects.").
+				Default(false).
+				Advanced(),
+			service.NewBoolField(osiFieldUpdatesOnly).
+				Description("Only consume changes made after the input connects, ignoring the current objects of the bucket.").
+				Default(false).
+				Advanced(),
+		).
+		Fields(connectionTailFields()...)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"nats_object_store", natsObjectStoreInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			reader, err := newObjectStoreReader(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksToggled(conf, reader)
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+}
+
+type objectStoreReader struct {
+	connDetails   connectionDetails
+	bucket        string
+	ignoreDeletes bool
+	updatesOnly   bool
+
+	log *service.Logger
+
+	shutSig *shutdown.Signaller
+
+	connMut  sync.Mutex
+	natsConn *nats.Conn
+	store    jetstream.ObjectStore
+	watcher  jetstream.ObjectWatcher
+}
+
+func newObjectStoreReader(conf *service.ParsedConfig, mgr *service.Resources) (*objectStoreReader, error) {
+	r := &objectStoreReader{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if r.connDetails, err = connectionDetailsFromParsed(conf, mgr); err != nil {
+		return nil, err
+	}
+
+	if r.bucket, err = conf.FieldString(osFieldBucket); err != nil {
+		return nil, err
+	}
+
+	if r.ignoreDeletes, err = conf.FieldBool(osiFieldIgnoreDeletes); err != nil {
+		return nil, err
+	}
+
+	if r.updatesOnly, err = conf.FieldBool(osiFieldUpdatesOnly); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *objectStoreReader) Connect(ctx context.Context) (err error) {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.natsConn != nil {
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			if r.watcher != nil {
+				_ = r.watcher.Stop()
+				r.watcher = nil
+			}
+			if r.natsConn != nil {
+				r.natsConn.Close()
+				r.natsConn = nil
+			}
+		}
+	}()
+
+	if r.natsConn, err = r.connDetails.get(ctx); err != nil {
+		return err
+	}
+
+	js, err := jetstream.New(r.natsConn)
+	if err != nil {
+		return err
+	}
+
+	if r.store, err = js.ObjectStore(ctx, r.bucket); err != nil {
+		return err
+	}
+
+	var watchOpts []jetstream.WatchOpt
+	if r.ignoreDeletes {
+		watchOpts = append(watchOpts, jetstream.IgnoreDeletes())
+	}
+	if r.updatesOnly {
+		watchOpts = append(watchOpts, jetstream.UpdatesOnly())
+	}
+
+	r.watcher, err = r.store.Watch(ctx, watchOpts...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *objectStoreReader) disconnect() {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.watcher != nil {
+		_ = r.watcher.Stop()
+		r.watcher = nil
+	}
+	if r.natsConn != nil {
+		r.natsConn.Close()
+		r.natsConn = nil
+	}
+	r.store = nil
+}
+
+func (r *objectStoreReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	r.connMut.Lock()
+	watcher, store := r.watcher, r.store
+	r.connMut.Unlock()
+
+	if watcher == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	for {
+		var info *jetstream.ObjectInfo
+		var open bool
+		select {
+		case info, open = <-watcher.Updates():
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		if !open {
+			r.disconnect()
+			return nil, nil, service.ErrNotConnected
+		}
+
+		// A nil entry marks the end of the current objects of the bucket.
+		if info == nil {
+			continue
+		}
+
+		r.log.With(
+			metaObjectStoreBucket, info.Bucket,
+			metaObjectStoreName, info.Name,
+			metaObjectStoreDeleted, info.Deleted,
+		).Debugf("Received object store update")
+
+		var content []byte
+		if !info.Deleted {
+			var err error
+			if content, err = store.GetBytes(ctx, info.Name); err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				// Restart the watch so that the object is consumed again.
+				r.log.Errorf("Failed to get object '%v', restarting watch: %v", info.Name, err)
+				r.disconnect()
+				return nil, nil, service.ErrNotConnected
+			}
+		}
+
+		return newMessageFromObjectInfo(info, content), func(ctx context.Context, res error) error {
+			return nil
+		}, nil
+	}
+}
+
+func (r *objectStoreReader) Close(ctx context.Context) error {
+	go func() {
+		r.disconnect()
+		r.shutSig.TriggerHasStopped()
+	}()
+	select {
+	case <-r.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestInputObjectStoreParse(t *testing.T) {
+	spec := natsObjectStoreInputConfig()
+	env := service.NewEnvironment()
+
+	inputConfig := `
+urls: [ url1, url2 ]
+bucket: testbucket
+ignore_deletes: true
+updates_only: true
+auth:
+  token: test auth inline user token
+`
+
+	conf, err := spec.ParseYAML(inputConfig, env)
+	require.NoError(t, err)
+
+	r, err := newObjectStoreReader(conf, service.MockResources())
+	require.NoError(t, err)
+
+	assert.Equal(t, "url1,url2", r.connDetails.urls)
+	assert.Equal(t, "testbucket", r.bucket)
+	assert.True(t, r.ignoreDeletes)
+	assert.True(t, r.updatesOnly)
+	assert.Equal(t, "test auth inline user token", r.connDetails.authConf.Token)
+}
+
+func TestInputObjectStoreMessage(t *testing.T) {
+	modTime := time.Unix(1700000000, 0).UTC()
+	info := &jetstream.ObjectInfo{
+		ObjectMeta: jetstream.ObjectMeta{
+			Name:     "foo.txt",
+			Metadata: map[string]string{"owner": "bar"},
+		},
+		Bucket:  "testbucket",
+		NUID:    "abc",
+		Size:    5,
+		ModTime: modTime,
+		Digest:  "SHA-256=xyz",
+	}
+
+	msg := newMessageFromObjectInfo(info, []byte("hello"))
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	for k, v := range map[string]any{
+		"owner":                "bar",
+		metaObjectStoreBucket:  "testbucket",
+		metaObjectStoreName:    "foo.txt",
+		metaObjectStoreNUID:    "abc",
+		metaObjectStoreSize:    uint64(5),
+		metaObjectStoreDigest:  "SHA-256=xyz",
+		metaObjectStoreModTime: modTime,
+		metaObjectStoreDeleted: false,
+	} {
+		actual, exists := msg.MetaGetMut(k)
+		require.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+}
@@ -40,3 +40,29 @@ func newMessageFromKVEntry(entry jetstream.KeyValueEntry) *service.Message {
 
 	return msg
 }
+
+const (
+	metaObjectStoreBucket  = "nats_object_store_bucket"
+	metaObjectStoreName    = "nats_object_store_name"
+	metaObjectStoreNUID    = "nats_object_store_nuid"
+	metaObjectStoreSize    = "nats_object_store_size"
+	metaObjectStoreDigest  = "nats_object_store_digest"
+	metaObjectStoreModTime = "nats_object_store_mod_time"
+	metaObjectStoreDeleted = "nats_object_store_deleted"
+)
+
+func newMessageFromObjectInfo(info *jetstream.ObjectInfo, content []byte) *service.Message {
+	msg := service.NewMessage(content)
+	for k, v := range info.Metadata {
+		msg.MetaSetMut(k, v)
+	}
+	msg.MetaSetMut(metaObjectStoreBucket, info.Bucket)
+	msg.MetaSetMut(metaObjectStoreName, info.Name)
+	msg.MetaSetMut(metaObjectStoreNUID, info.NUID)
+	msg.MetaSetMut(metaObjectStoreSize, info.Size)
+	msg.MetaSetMut(metaObjectStoreDigest, info.Digest)
+	msg.MetaSetMut(metaObjectStoreModTime, info.ModTime)
+	msg.MetaSetMut(metaObjectStoreDeleted, info.Deleted)
+
+	return msg
+}
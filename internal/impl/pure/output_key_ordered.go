@@ -0,0 +1,321 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	koFieldKey      = "key"
+	koFieldLanes    = "lanes"
+	koFieldOutput   = "output"
+	koFieldRetries  = "retries"
+	koFieldBatching = "batching"
+)
+
+func keyOrderedOutputConfig() *service.ConfigSpec {
+	retriesDefaults := backoff.NewExponentialBackOff()
+	retriesDefaults.InitialInterval = time.Millisecond * 500
+	retriesDefaults.MaxInterval = time.Second * 10
+	retriesDefaults.MaxElapsedTime = 0
+
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Writes messages to a child output in parallel whilst preserving the order of messages that share a key.").
+		Description(`
+Each message of a batch is assigned to one of a number of lanes by hashing its interpolated `+"`"+koFieldKey+"`"+`. The messages of each lane are written to the child output as a single batch in their original order, and different lanes are written in parallel, which means that messages sharing a key are always delivered in the order they were consumed whilst messages of different keys do not block each other.
+
+When a write fails only the messages of the lane that failed are retried, according to `+"`"+koFieldRetries+"`"+`, and the remaining lanes are not written again. If the child output rejects only some of the messages of a lane, as is the case with outputs that report errors per message, then only those messages are retried.
+
+Up to `+"`max_in_flight`"+` batches are written at the same time, where the messages of each lane are written in the order that their batches reached this output. A lane that is retrying a write therefore only holds back the messages of later batches that belong to the same lane, and the other lanes keep moving. In order to get the most out of this output a `+"`"+koFieldBatching+"`"+` policy should be configured so that each batch contains a wide range of keys, and the child output should have a `+"`max_in_flight`"+` of at least the number of `+"`"+koFieldLanes+"`"+` for lanes to be written in parallel.
+
+Ordering of a key is only guaranteed for as long as failed writes are retried, and therefore by default retries continue indefinitely. If a maximum elapsed time is configured the messages of a lane are rejected once it is exceeded, and will be reattempted according to the input, potentially after messages of the same key that were consumed later.`).
+		Fields(
+			service.NewInterpolatedStringField(koFieldKey).
+				Description("The key that messages are ordered by.").
+				Example(`${! meta("kafka_key") }`).
+				Example(`${! this.user.id }`),
+			service.NewIntField(koFieldLanes).
+				Description("The number of lanes that messages are distributed across, which is the maximum number of parallel writes.").
+				Default(16).
+				LintRule(`root = if this <= 0 { [ "lanes must be larger than zero" ] }`),
+			service.NewOutputField(koFieldOutput).
+				Description("The child output to write messages to."),
+			service.NewOutputMaxInFlightField().
+				Description("The maximum number of batches to have in flight at any given time. Messages of a lane are written in the order their batches reached the output regardless of this value."),
+			service.NewBackOffField(koFieldRetries, true, retriesDefaults).
+				Description("Determines how failed writes of a lane are retried.").
+				Advanced(),
+			service.NewBatchPolicyField(koFieldBatching),
+		).
+		Example("Ordered per Entity", "Writes events to an HTTP endpoint with up to 32 requests in parallel, where events of the same account are always delivered in order.", `
+output:
+  key_ordered:
+    key: ${! this.account_id }
+    lanes: 32
+    batching:
+      count: 500
+      period: 100ms
+    output:
+      http_client:
+        url: http://localhost:8080/events
+        verb: POST
+        max_in_flight: 32
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"key_ordered", keyOrderedOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, mif int, err error) {
+			if mif, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			if batchPol, err = conf.FieldBatchPolicy(koFieldBatching); err != nil {
+				return
+			}
+			out, err = newKeyOrderedOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type keyOrderedWriter interface {
+	WriteBatch(ctx context.Context, batch service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type keyOrderedOutput struct {
+	log     *service.Logger
+	key     *service.InterpolatedString
+	retries *backoff.ExponentialBackOff
+	child   keyOrderedWriter
+
+	// The tail of each lane is closed once the most recent write to the lane
+	// has finished, and the next write to the lane waits for it.
+	tailsMut sync.Mutex
+	tails    []chan struct{}
+}
+
+func newKeyOrderedOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*keyOrderedOutput, error) {
+	key, err := conf.FieldInterpolatedString(koFieldKey)
+	if err != nil {
+		return nil, err
+	}
+	lanes, err := conf.FieldInt(koFieldLanes)
+	if err != nil {
+		return nil, err
+	}
+	if lanes <= 0 {
+		return nil, errors.New("lanes must be larger than zero")
+	}
+	retries, err := conf.FieldBackOff(koFieldRetries)
+	if err != nil {
+		return nil, err
+	}
+	child, err := conf.FieldOutput(koFieldOutput)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyOrderedOutput(mgr, key, lanes, retries, child), nil
+}
+
+func newKeyOrderedOutput(mgr *service.Resources, key *service.InterpolatedString, lanes int, retries *backoff.ExponentialBackOff, child keyOrderedWriter) *keyOrderedOutput {
+	return &keyOrderedOutput{
+		log:     mgr.Logger(),
+		key:     key,
+		retries: retries,
+		child:   child,
+		tails:   make([]chan struct{}, lanes),
+	}
+}
+
+func (k *keyOrderedOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// writeLane writes the messages of a lane to the child output, retrying those
+// that failed until they succeed or the retry policy gives up. The errors of
+// messages that could not be written are returned keyed by their index within
+// the lane.
+func (k *keyOrderedOutput) writeLane(ctx context.Context, batch service.MessageBatch) map[int]error {
+	boff := *k.retries
+	boff.Reset()
+
+	pending := make([]int, len(batch))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for {
+		pendingBatch := make(service.MessageBatch, len(pending))
+		for j, i := range pending {
+			pendingBatch[j] = batch[i]
+		}
+		index := pendingBatch.Index()
+
+		err := k.child.WriteBatch(ctx, pendingBatch)
+		if err == nil {
+			return nil
+		}
+
+		failed := map[int]error{}
+		var bErr *service.BatchError
+		if errors.As(err, &bErr) {
+			bErr.WalkMessagesIndexedBy(index, func(j int, _ *service.Message, mErr error) bool {
+				if mErr != nil {
+					failed[pending[j]] = mErr
+				}
+				return true
+			})
+		}
+		if len(failed) == 0 {
+			for _, i := range pending {
+				failed[i] = err
+			}
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return failed
+		}
+		k.log.Warnf("Failed to write %v messages of ordered lane, retrying in %v: %v", len(failed), wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			for i := range failed {
+				failed[i] = ctx.Err()
+			}
+			return failed
+		}
+
+		retry := pending[:0]
+		for _, i := range pending {
+			if _, exists := failed[i]; exists {
+				retry = append(retry, i)
+			}
+		}
+		pending = retry
+	}
+}
+
+func (k *keyOrderedOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var (
+		errMut   sync.Mutex
+		batchErr *service.BatchError
+	)
+	failed := func(i int, err error) {
+		errMut.Lock()
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, err)
+		}
+		batchErr.Failed(i, err)
+		errMut.Unlock()
+	}
+
+	keyExec := batch.InterpolationExecutor(k.key)
+	lanes := make([][]int, len(k.tails))
+	for i := range batch {
+		key, err := keyExec.TryString(i)
+		if err != nil {
+			failed(i, fmt.Errorf("key interpolation error: %w", err))
+			continue
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		lane := int(h.Sum64() % uint64(len(k.tails)))
+		lanes[lane] = append(lanes[lane], i)
+	}
+
+	// Take the place of this batch at the end of each of its lanes, which
+	// orders the writes of a lane by the arrival of their batches.
+	prevs := make([]chan struct{}, len(lanes))
+	dones := make([]chan struct{}, len(lanes))
+	k.tailsMut.Lock()
+	for lane, indexes := range lanes {
+		if len(indexes) == 0 {
+			continue
+		}
+		prevs[lane], dones[lane] = k.tails[lane], make(chan struct{})
+		k.tails[lane] = dones[lane]
+	}
+	k.tailsMut.Unlock()
+
+	var wg sync.WaitGroup
+	for lane, indexes := range lanes {
+		if len(indexes) == 0 {
+			continue
+		}
+
+		laneBatch := make(service.MessageBatch, len(indexes))
+		for j, i := range indexes {
+			laneBatch[j] = batch[i]
+		}
+
+		wg.Add(1)
+		go func(prev, done chan struct{}) {
+			defer wg.Done()
+
+			if prev != nil {
+				select {
+				case <-prev:
+				case <-ctx.Done():
+					for _, i := range indexes {
+						failed(i, ctx.Err())
+					}
+					// The next write of the lane must still wait for the
+					// previous one.
+					go func() {
+						<-prev
+						close(done)
+					}()
+					return
+				}
+			}
+			defer close(done)
+
+			for j, err := range k.writeLane(ctx, laneBatch) {
+				failed(indexes[j], err)
+			}
+		}(prevs[lane], dones[lane])
+	}
+	wg.Wait()
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (k *keyOrderedOutput) Close(ctx context.Context) error {
+	return k.child.Close(ctx)
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockKeyOrderedWriter struct {
+	mut      sync.Mutex
+	writes   map[string][]string
+	failures map[string]int
+	attempts map[string]int
+}
+
+func (m *mockKeyOrderedWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	key, _ := batch[0].MetaGet("key")
+	m.attempts[key]++
+	if m.failures[key] > 0 {
+		m.failures[key]--
+		return errors.New("nope")
+	}
+	for _, msg := range batch {
+		k, _ := msg.MetaGet("key")
+		b, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		m.writes[k] = append(m.writes[k], string(b))
+	}
+	return nil
+}
+
+func (m *mockKeyOrderedWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestKeyOrderedOutput(t *testing.T) {
+	key, err := service.NewInterpolatedString(`${! meta("key") }`)
+	require.NoError(t, err)
+
+	retries := backoff.NewExponentialBackOff()
+	retries.InitialInterval = time.Millisecond
+	retries.MaxInterval = time.Millisecond
+	retries.MaxElapsedTime = 0
+
+	// The keys a, b and c hash into distinct lanes.
+	w := &mockKeyOrderedWriter{
+		writes:   map[string][]string{},
+		failures: map[string]int{"b": 2},
+		attempts: map[string]int{},
+	}
+	o := newKeyOrderedOutput(service.MockResources(), key, 1024, retries, w)
+
+	var batch service.MessageBatch
+	for _, kv := range [][2]string{
+		{"a", "a1"}, {"b", "b1"}, {"a", "a2"}, {"c", "c1"}, {"b", "b2"}, {"a", "a3"},
+	} {
+		msg := service.NewMessage([]byte(kv[1]))
+		msg.MetaSetMut("key", kv[0])
+		batch = append(batch, msg)
+	}
+
+	require.NoError(t, o.WriteBatch(context.Background(), batch))
+
+	assert.Equal(t, map[string][]string{
+		"a": {"a1", "a2", "a3"},
+		"b": {"b1", "b2"},
+		"c": {"c1"},
+	}, w.writes)
+	assert.Equal(t, map[string]int{"a": 1, "b": 3, "c": 1}, w.attempts)
+}
+
+func TestKeyOrderedOutputGivesUp(t *testing.T) {
+	key, err := service.NewInterpolatedString(`${! meta("key") }`)
+	require.NoError(t, err)
+
+	retries := backoff.NewExponentialBackOff()
+	retries.InitialInterval = time.Millisecond
+	retries.MaxInterval = time.Millisecond
+	retries.MaxElapsedTime = time.Millisecond * 20
+
+	w := &mockKeyOrderedWriter{
+		writes:   map[string][]string{},
+		failures: map[string]int{"b": 1 << 30},
+		attempts: map[string]int{},
+	}
+	o := newKeyOrderedOutput(service.MockResources(), key, 1024, retries, w)
+
+	var batch service.MessageBatch
+	for _, k := range []string{"a", "b", "a", "b"} {
+		msg := service.NewMessage([]byte(k))
+		msg.MetaSetMut("key", k)
+		batch = append(batch, msg)
+	}
+	index := batch.Index()
+
+	err = o.WriteBatch(context.Background(), batch)
+	require.Error(t, err)
+
+	var bErr *service.BatchError
+	require.ErrorAs(t, err, &bErr)
+
+	var failed []int
+	bErr.WalkMessagesIndexedBy(index, func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 3}, failed)
+	assert.Equal(t, []string{"a", "a"}, w.writes["a"])
+}
+
+type keyOrderedWriterFunc func(ctx context.Context, batch service.MessageBatch) error
+
+func (f keyOrderedWriterFunc) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	return f(ctx, batch)
+}
+
+func (f keyOrderedWriterFunc) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestKeyOrderedOutputRetryDoesNotBlockOtherKeys(t *testing.T) {
+	key, err := service.NewInterpolatedString(`${! meta("key") }`)
+	require.NoError(t, err)
+
+	retries := backoff.NewExponentialBackOff()
+	retries.InitialInterval = time.Millisecond
+	retries.MaxInterval = time.Millisecond
+	retries.MaxElapsedTime = 0
+
+	var (
+		mut      sync.Mutex
+		writes   []string
+		bHealthy bool
+	)
+	o := newKeyOrderedOutput(service.MockResources(), key, 1024, retries, keyOrderedWriterFunc(func(ctx context.Context, batch service.MessageBatch) error {
+		mut.Lock()
+		defer mut.Unlock()
+		if k, _ := batch[0].MetaGet("key"); k == "b" && !bHealthy {
+			return errors.New("nope")
+		}
+		for _, msg := range batch {
+			b, err := msg.AsBytes()
+			if err != nil {
+				return err
+			}
+			writes = append(writes, string(b))
+		}
+		return nil
+	}))
+
+	newBatch := func(kvs ...[2]string) (batch service.MessageBatch) {
+		for _, kv := range kvs {
+			msg := service.NewMessage([]byte(kv[1]))
+			msg.MetaSetMut("key", kv[0])
+			batch = append(batch, msg)
+		}
+		return
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- o.WriteBatch(ctx, newBatch([2]string{"a", "a1"}, [2]string{"b", "b1"}))
+	}()
+	require.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return len(writes) == 1
+	}, time.Second*5, time.Millisecond)
+
+	secondErr := make(chan error, 1)
+	go func() {
+		secondErr <- o.WriteBatch(ctx, newBatch([2]string{"b", "b2"}, [2]string{"a", "a2"}))
+	}()
+
+	// The lane of a is delivered whilst the lane of b is still retrying.
+	require.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return len(writes) == 2
+	}, time.Second*5, time.Millisecond)
+
+	mut.Lock()
+	assert.Equal(t, []string{"a1", "a2"}, writes)
+	bHealthy = true
+	mut.Unlock()
+
+	require.NoError(t, <-firstErr)
+	require.NoError(t, <-secondErr)
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2"}, writes)
+}
+
+func TestKeyOrderedOutputPartialErrors(t *testing.T) {
+	key, err := service.NewInterpolatedString(`${! meta("key") }`)
+	require.NoError(t, err)
+
+	retries := backoff.NewExponentialBackOff()
+	retries.InitialInterval = time.Millisecond
+	retries.MaxInterval = time.Millisecond
+	retries.MaxElapsedTime = 0
+
+	var (
+		writes   []string
+		attempts [][]string
+	)
+	o := newKeyOrderedOutput(service.MockResources(), key, 1, retries, keyOrderedWriterFunc(func(ctx context.Context, batch service.MessageBatch) error {
+		var attempt []string
+		var bErr *service.BatchError
+		for i, msg := range batch {
+			b, err := msg.AsBytes()
+			if err != nil {
+				return err
+			}
+			attempt = append(attempt, string(b))
+			if string(b) == "b1" && len(attempts) == 0 {
+				if bErr == nil {
+					bErr = service.NewBatchError(batch, errors.New("nope"))
+				}
+				bErr.Failed(i, errors.New("nope"))
+				continue
+			}
+			writes = append(writes, string(b))
+		}
+		attempts = append(attempts, attempt)
+		if bErr != nil {
+			return bErr
+		}
+		return nil
+	}))
+
+	var batch service.MessageBatch
+	for _, kv := range [][2]string{{"a", "a1"}, {"b", "b1"}, {"c", "c1"}} {
+		msg := service.NewMessage([]byte(kv[1]))
+		msg.MetaSetMut("key", kv[0])
+		batch = append(batch, msg)
+	}
+
+	require.NoError(t, o.WriteBatch(context.Background(), batch))
+	assert.Equal(t, [][]string{{"a1", "b1", "c1"}, {"b1"}}, attempts)
+	assert.Equal(t, []string{"a1", "c1", "b1"}, writes)
+}
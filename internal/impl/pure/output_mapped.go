@@ -0,0 +1,296 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redpanda-data/benthos/v4/public/bloblang"
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	moFieldMapping       = "mapping"
+	moFieldOutputs       = "outputs"
+	moFieldOutputsName   = "name"
+	moFieldOutputsOutput = "output"
+)
+
+func mappedOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Routes each message to one of a set of named child outputs, where the name of the target is resolved per message with a Bloblang mapping.").
+		Description(`
+The `+"`"+moFieldMapping+"`"+` is executed against each message of a batch and must result in a string matching the name of one of the configured `+"`"+moFieldOutputs+"`"+`. Messages that resolve to the same target are written to it as a single batch, and different targets are written to in parallel.
+
+Child outputs are only initialised the first time a message is routed to them, which means large sets of targets (one per tenant, region, etc) only consume resources once they are actually in use. If a child output fails to initialise the error is logged once, and all messages routed to it are rejected with that error from then on.
+
+If the mapping fails, or resolves to the name of an output that does not exist, the message is rejected and will be reattempted according to the input. If the mapping deletes the root of the result (`+"`root = deleted()`"+`) the message is dropped and acknowledged.
+
+== Metrics
+
+This output emits the counters `+"`mapped_output_sent` and `mapped_output_error`"+`, both labelled with the name of the `+"`target`"+` output, which track the number of messages successfully written to and failed to be written to each target respectively.`).
+		Fields(
+			service.NewBloblangField(moFieldMapping).
+				Description("A xref:guides:bloblang/about.adoc[Bloblang mapping] that resolves the name of the target output for each message.").
+				Example(`root = meta("tenant")`).
+				Example(`root = if this.region == "eu" { "europe" } else { "rest_of_world" }`),
+			service.NewObjectListField(moFieldOutputs,
+				service.NewStringField(moFieldOutputsName).
+					Description("The name of the output, which is matched against the result of the mapping."),
+				service.NewOutputField(moFieldOutputsOutput).
+					Description("The output to route messages to."),
+			).Description("A list of named child outputs that messages can be routed to."),
+		).
+		LintRule(`root = if this.outputs.or([]).map_each(o -> o.name).unique().length() != this.outputs.or([]).length() { [ "output names must be unique" ] }`).
+		Example("Region Routing", "Messages are written to a bucket matching the region of the document, or dropped when the region is unknown.", `
+output:
+  mapped:
+    mapping: |
+      root = match this.region {
+        "eu" => "eu_bucket",
+        "us" => "us_bucket",
+        _ => deleted(),
+      }
+    outputs:
+      - name: eu_bucket
+        output:
+          aws_s3:
+            bucket: data-eu
+            region: eu-west-1
+            path: ${! uuid_v4() }.json
+      - name: us_bucket
+        output:
+          aws_s3:
+            bucket: data-us
+            region: us-east-1
+            path: ${! uuid_v4() }.json
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"mapped", mappedOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, mif int, err error) {
+			mif = 1
+			out, err = newMappedOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type mappedWriter interface {
+	WriteBatch(ctx context.Context, batch service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type mappedTarget struct {
+	ctor func() (mappedWriter, error)
+
+	mut sync.Mutex
+	w   mappedWriter
+	err error
+}
+
+// writer returns the writer of the target, initialising it on first use. A
+// failure to initialise is remembered and returned for all subsequent calls,
+// as it is caused by the configuration of the child output.
+func (t *mappedTarget) writer() (w mappedWriter, first bool, err error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if t.w != nil || t.err != nil {
+		return t.w, false, t.err
+	}
+	if t.w, t.err = t.ctor(); t.err != nil {
+		t.err = fmt.Errorf("failed to initialise output: %w", t.err)
+	}
+	return t.w, true, t.err
+}
+
+func (t *mappedTarget) close(ctx context.Context) error {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if t.w == nil {
+		return nil
+	}
+	err := t.w.Close(ctx)
+	t.w = nil
+	return err
+}
+
+type mappedOutput struct {
+	log     *service.Logger
+	mapping *bloblang.Executor
+	targets map[string]*mappedTarget
+
+	mSent  *service.MetricCounter
+	mError *service.MetricCounter
+}
+
+func newMappedOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*mappedOutput, error) {
+	mapping, err := conf.FieldBloblang(moFieldMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	outConfs, err := conf.FieldObjectList(moFieldOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctors := make(map[string]func() (mappedWriter, error), len(outConfs))
+	for i, oConf := range outConfs {
+		name, err := oConf.FieldString(moFieldOutputsName)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, fmt.Errorf("output %v has an empty name", i)
+		}
+		if _, exists := ctors[name]; exists {
+			return nil, fmt.Errorf("output name %q is duplicated", name)
+		}
+		ctors[name] = func() (mappedWriter, error) {
+			return oConf.FieldOutput(moFieldOutputsOutput)
+		}
+	}
+	return newMappedOutput(mgr, mapping, ctors), nil
+}
+
+func newMappedOutput(mgr *service.Resources, mapping *bloblang.Executor, ctors map[string]func() (mappedWriter, error)) *mappedOutput {
+	m := &mappedOutput{
+		log:     mgr.Logger(),
+		mapping: mapping,
+		targets: make(map[string]*mappedTarget, len(ctors)),
+		mSent:   mgr.Metrics().NewCounter("mapped_output_sent", "target"),
+		mError:  mgr.Metrics().NewCounter("mapped_output_error", "target"),
+	}
+	for name, ctor := range ctors {
+		m.targets[name] = &mappedTarget{ctor: ctor}
+	}
+	return m
+}
+
+func (m *mappedOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (m *mappedOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var (
+		errMut   sync.Mutex
+		batchErr *service.BatchError
+	)
+	failed := func(i int, err error) {
+		errMut.Lock()
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, err)
+		}
+		batchErr.Failed(i, err)
+		errMut.Unlock()
+	}
+
+	exec := batch.BloblangExecutor(m.mapping)
+	groups := map[string][]int{}
+	for i := range batch {
+		res, err := exec.Query(i)
+		if err != nil {
+			failed(i, fmt.Errorf("target mapping failed: %w", err))
+			continue
+		}
+		if res == nil {
+			continue
+		}
+
+		nameBytes, err := res.AsBytes()
+		if err != nil {
+			failed(i, fmt.Errorf("target mapping failed: %w", err))
+			continue
+		}
+		name := string(nameBytes)
+		groups[name] = append(groups[name], i)
+	}
+
+	var wg sync.WaitGroup
+	for name, indexes := range groups {
+		target, exists := m.targets[name]
+		if !exists {
+			err := fmt.Errorf("target output %q does not exist", name)
+			for _, i := range indexes {
+				failed(i, err)
+			}
+			m.mError.Incr(int64(len(indexes)), name)
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, target *mappedTarget, indexes []int) {
+			defer wg.Done()
+
+			err := m.writeTarget(ctx, name, target, batch, indexes)
+			if err != nil {
+				m.log.Debugf("Failed to write to mapped output %v: %v", name, err)
+				for _, i := range indexes {
+					failed(i, err)
+				}
+				m.mError.Incr(int64(len(indexes)), name)
+				return
+			}
+			m.mSent.Incr(int64(len(indexes)), name)
+		}(name, target, indexes)
+	}
+	wg.Wait()
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (m *mappedOutput) writeTarget(ctx context.Context, name string, target *mappedTarget, batch service.MessageBatch, indexes []int) error {
+	w, first, err := target.writer()
+	if err != nil {
+		if first {
+			m.log.Errorf("Mapped output %v: %v", name, err)
+		}
+		return err
+	}
+
+	subBatch := make(service.MessageBatch, 0, len(indexes))
+	for _, i := range indexes {
+		subBatch = append(subBatch, batch[i])
+	}
+	return w.WriteBatch(ctx, subBatch)
+}
+
+func (m *mappedOutput) Close(ctx context.Context) error {
+	var errs []error
+	for _, t := range m.targets {
+		if err := t.close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
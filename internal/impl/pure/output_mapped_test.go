@@ -0,0 +1,164 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/bloblang"
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockMappedWriter struct {
+	mut      sync.Mutex
+	contents []string
+	err      error
+	closed   bool
+}
+
+func (m *mockMappedWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for _, msg := range batch {
+		b, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		m.contents = append(m.contents, string(b))
+	}
+	return nil
+}
+
+func (m *mockMappedWriter) Close(ctx context.Context) error {
+	m.closed = true
+	return nil
+}
+
+func TestMappedOutputRouting(t *testing.T) {
+	mapping, err := bloblang.Parse(`root = if meta("target") == "drop" { deleted() } else { meta("target") }`)
+	require.NoError(t, err)
+
+	fooW, barW, bazW, quxW := &mockMappedWriter{}, &mockMappedWriter{}, &mockMappedWriter{}, &mockMappedWriter{}
+	var initsMut sync.Mutex
+	inits := map[string]int{}
+	ctor := func(name string, w mappedWriter) func() (mappedWriter, error) {
+		return func() (mappedWriter, error) {
+			initsMut.Lock()
+			inits[name]++
+			initsMut.Unlock()
+			return w, nil
+		}
+	}
+	o := newMappedOutput(service.MockResources(), mapping, map[string]func() (mappedWriter, error){
+		"foo": ctor("foo", fooW),
+		"bar": ctor("bar", barW),
+		"baz": ctor("baz", bazW),
+		"qux": ctor("qux", quxW),
+	})
+
+	newMsg := func(content, target string) *service.Message {
+		msg := service.NewMessage([]byte(content))
+		msg.MetaSetMut("target", target)
+		return msg
+	}
+
+	ctx := context.Background()
+	require.NoError(t, o.WriteBatch(ctx, service.MessageBatch{
+		newMsg("a", "foo"),
+		newMsg("b", "bar"),
+		newMsg("c", "drop"),
+		newMsg("d", "foo"),
+	}))
+
+	assert.Equal(t, []string{"a", "d"}, fooW.contents)
+	assert.Equal(t, []string{"b"}, barW.contents)
+	assert.Empty(t, bazW.contents)
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1}, inits, "unused targets should not be initialised")
+
+	require.NoError(t, o.WriteBatch(ctx, service.MessageBatch{newMsg("e", "baz")}))
+	require.NoError(t, o.WriteBatch(ctx, service.MessageBatch{newMsg("f", "baz")}))
+	assert.Equal(t, []string{"e", "f"}, bazW.contents)
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1, "baz": 1}, inits)
+
+	require.NoError(t, o.Close(ctx))
+	assert.True(t, fooW.closed)
+	assert.True(t, barW.closed)
+	assert.True(t, bazW.closed)
+	assert.False(t, quxW.closed)
+	assert.Zero(t, inits["qux"], "a target that is never routed to should never be built")
+}
+
+func TestMappedOutputErrors(t *testing.T) {
+	mapping, err := bloblang.Parse(`root = meta("target")`)
+	require.NoError(t, err)
+
+	okW, badW := &mockMappedWriter{}, &mockMappedWriter{err: errors.New("nope")}
+	var brokenInits int
+	o := newMappedOutput(service.MockResources(), mapping, map[string]func() (mappedWriter, error){
+		"ok":  func() (mappedWriter, error) { return okW, nil },
+		"bad": func() (mappedWriter, error) { return badW, nil },
+		"broken": func() (mappedWriter, error) {
+			brokenInits++
+			return nil, errors.New("failed to build")
+		},
+	})
+
+	newMsg := func(content, target string) *service.Message {
+		msg := service.NewMessage([]byte(content))
+		if target != "" {
+			msg.MetaSetMut("target", target)
+		}
+		return msg
+	}
+
+	batch := service.MessageBatch{
+		newMsg("a", "ok"),
+		newMsg("b", "bad"),
+		newMsg("c", "missing"),
+		newMsg("d", "broken"),
+		newMsg("e", ""),
+		newMsg("f", "ok"),
+	}
+
+	for j := 0; j < 2; j++ {
+		index := batch.Index()
+
+		err = o.WriteBatch(context.Background(), batch)
+		require.Error(t, err)
+
+		var bErr *service.BatchError
+		require.ErrorAs(t, err, &bErr)
+
+		var failed []int
+		bErr.WalkMessagesIndexedBy(index, func(i int, _ *service.Message, err error) bool {
+			if err != nil {
+				failed = append(failed, i)
+			}
+			return true
+		})
+		assert.Equal(t, []int{1, 2, 3, 4}, failed)
+	}
+	assert.Equal(t, []string{"a", "f", "a", "f"}, okW.contents)
+	assert.Equal(t, 1, brokenInits, "a target that failed to initialise should not be rebuilt")
+}
@@ -0,0 +1,240 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	shFieldOutput      = "output"
+	shFieldShadow      = "shadow"
+	shFieldPercentage  = "percentage"
+	shFieldMaxInFlight = "max_in_flight"
+)
+
+func shadowOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Writes messages to a primary output and mirrors a percentage of them to a shadow output, without the shadow affecting delivery.").
+		Description(`
+Messages are always written to the primary `+"`"+shFieldOutput+"`"+`, and the result of that write alone determines whether messages are acknowledged. Once the primary write has completed a randomly sampled `+"`"+shFieldPercentage+"`"+` of the messages are copied and written to the `+"`"+shFieldShadow+"`"+` output in the background, and the outcome of the shadow write is only recorded as metrics. This makes it possible to safely trial a new sink, or a new version of a sink with different processors, against production traffic.
+
+Shadow writes are not retried, and when `+"`"+shFieldMaxInFlight+"`"+` shadow writes are already in progress further shadow copies are dropped rather than applying back pressure to the primary output.
+
+== Metrics
+
+This output emits the counter `+"`shadow_outcome`"+` for each shadowed message, labelled with the outcome of the `+"`primary`"+` and the `+"`shadow`"+` writes, which are each either `+"`success` or `failure`"+`. Messages where the two labels differ are those where the outputs diverged. The counter `+"`shadow_dropped`"+` is incremented for each message that was sampled but dropped due to the limit of shadow writes in flight.`).
+		Fields(
+			service.NewOutputField(shFieldOutput).
+				Description("The primary output, which determines the acknowledgement of messages."),
+			service.NewOutputField(shFieldShadow).
+				Description("The shadow output to mirror messages to."),
+			service.NewFloatField(shFieldPercentage).
+				Description("The percentage of messages to mirror to the shadow output, from 0 to 100.").
+				Example(10).
+				Example(0.5).
+				Default(100.0).
+				LintRule(`root = if this < 0 || this > 100 { ["field must be between 0 and 100"] }`),
+			service.NewOutputMaxInFlightField().
+				Description("The maximum number of batches to have in flight at a given time for both the primary and the shadow output."),
+		).
+		Example("Trial a New Sink", "Messages are delivered to an existing Kafka topic, and one in ten of them are also written to a new HTTP service that is being trialled.", `
+output:
+  shadow:
+    percentage: 10
+    output:
+      kafka_franz:
+        seed_brokers: [ localhost:9092 ]
+        topic: events
+    shadow:
+      http_client:
+        url: http://localhost:8080/events
+        verb: POST
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"shadow", shadowOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, mif int, err error) {
+			if mif, err = conf.FieldMaxInFlight(); err != nil {
+				return
+			}
+			out, err = newShadowOutputFromConfig(conf, mgr, mif)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type shadowWriter interface {
+	WriteBatch(ctx context.Context, batch service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type shadowOutput struct {
+	primary    shadowWriter
+	shadow     shadowWriter
+	percentage float64
+
+	mOutcome *service.MetricCounter
+	mDropped *service.MetricCounter
+	randFn   func() float64
+
+	shadowSlots chan struct{}
+	shadowWG    sync.WaitGroup
+	shutSig     *shutdown.Signaller
+}
+
+func newShadowOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources, mif int) (*shadowOutput, error) {
+	percentage, err := conf.FieldFloat(shFieldPercentage)
+	if err != nil {
+		return nil, err
+	}
+	primary, err := conf.FieldOutput(shFieldOutput)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := conf.FieldOutput(shFieldShadow)
+	if err != nil {
+		return nil, err
+	}
+	return newShadowOutput(mgr, primary, shadow, percentage, mif), nil
+}
+
+func newShadowOutput(mgr *service.Resources, primary, shadow shadowWriter, percentage float64, mif int) *shadowOutput {
+	return &shadowOutput{
+		primary:     primary,
+		shadow:      shadow,
+		percentage:  percentage,
+		mOutcome:    mgr.Metrics().NewCounter("shadow_outcome", "primary", "shadow"),
+		mDropped:    mgr.Metrics().NewCounter("shadow_dropped"),
+		randFn:      rand.Float64,
+		shadowSlots: make(chan struct{}, mif),
+		shutSig:     shutdown.NewSignaller(),
+	}
+}
+
+func (s *shadowOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// shadowFailures returns whether each message of a batch failed to be written
+// according to the error returned by the write.
+func shadowFailures(batch service.MessageBatch, index *service.Indexer, err error) []bool {
+	failures := make([]bool, len(batch))
+	if err == nil {
+		return failures
+	}
+
+	var bErr *service.BatchError
+	if !errors.As(err, &bErr) {
+		for i := range failures {
+			failures[i] = true
+		}
+		return failures
+	}
+	bErr.WalkMessagesIndexedBy(index, func(i int, _ *service.Message, err error) bool {
+		failures[i] = err != nil
+		return true
+	})
+	return failures
+}
+
+func shadowOutcome(failed bool) string {
+	if failed {
+		return "failure"
+	}
+	return "success"
+}
+
+func (s *shadowOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	// Copies are taken before the primary write as the primary output may
+	// mutate messages.
+	var shadowBatch service.MessageBatch
+	var sampled []int
+	for i, msg := range batch {
+		if s.randFn()*100 < s.percentage {
+			shadowBatch = append(shadowBatch, msg.Copy())
+			sampled = append(sampled, i)
+		}
+	}
+
+	primaryIndex := batch.Index()
+	primaryErr := s.primary.WriteBatch(ctx, batch)
+	if len(shadowBatch) == 0 {
+		return primaryErr
+	}
+
+	select {
+	case s.shadowSlots <- struct{}{}:
+	default:
+		s.mDropped.Incr(int64(len(shadowBatch)))
+		return primaryErr
+	}
+
+	primaryFailed := shadowFailures(batch, primaryIndex, primaryErr)
+
+	s.shadowWG.Add(1)
+	go func() {
+		defer func() {
+			<-s.shadowSlots
+			s.shadowWG.Done()
+		}()
+
+		shadowCtx, done := s.shutSig.SoftStopCtx(context.Background())
+		defer done()
+
+		shadowIndex := shadowBatch.Index()
+		shadowFailed := shadowFailures(shadowBatch, shadowIndex, s.shadow.WriteBatch(shadowCtx, shadowBatch))
+		for j, i := range sampled {
+			s.mOutcome.Incr(1, shadowOutcome(primaryFailed[i]), shadowOutcome(shadowFailed[j]))
+		}
+	}()
+	return primaryErr
+}
+
+func (s *shadowOutput) Close(ctx context.Context) error {
+	s.shutSig.TriggerSoftStop()
+
+	shadowsDone := make(chan struct{})
+	go func() {
+		s.shadowWG.Wait()
+		close(shadowsDone)
+	}()
+	select {
+	case <-shadowsDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if err := s.shadow.Close(ctx); err != nil {
+		return err
+	}
+	return s.primary.Close(ctx)
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+type mockShadowWriter struct {
+	mut    sync.Mutex
+	writes []string
+	err    error
+	block  chan struct{}
+}
+
+func (m *mockShadowWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for _, msg := range batch {
+		b, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		m.writes = append(m.writes, string(b))
+	}
+	return m.err
+}
+
+func (m *mockShadowWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+func shadowTestBatch(contents ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for _, c := range contents {
+		batch = append(batch, service.NewMessage([]byte(c)))
+	}
+	return batch
+}
+
+func TestShadowOutputMirrors(t *testing.T) {
+	primary := &mockShadowWriter{}
+	shadow := &mockShadowWriter{err: errors.New("shadow nope")}
+
+	s := newShadowOutput(service.MockResources(), primary, shadow, 50, 1)
+
+	rolls := []float64{0.1, 0.9, 0.4, 0.6}
+	s.randFn = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	require.NoError(t, s.WriteBatch(context.Background(), shadowTestBatch("a", "b", "c", "d")))
+	require.NoError(t, s.Close(context.Background()))
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, primary.writes)
+	assert.Equal(t, []string{"a", "c"}, shadow.writes)
+}
+
+func TestShadowOutputPrimaryError(t *testing.T) {
+	primary := &mockShadowWriter{err: errors.New("primary nope")}
+	shadow := &mockShadowWriter{}
+
+	s := newShadowOutput(service.MockResources(), primary, shadow, 100, 1)
+
+	require.EqualError(t, s.WriteBatch(context.Background(), shadowTestBatch("a", "b")), "primary nope")
+	require.NoError(t, s.Close(context.Background()))
+
+	assert.Equal(t, []string{"a", "b"}, shadow.writes)
+}
+
+func TestShadowOutputDropsWhenBusy(t *testing.T) {
+	primary := &mockShadowWriter{}
+	shadow := &mockShadowWriter{block: make(chan struct{})}
+
+	s := newShadowOutput(service.MockResources(), primary, shadow, 100, 1)
+
+	require.NoError(t, s.WriteBatch(context.Background(), shadowTestBatch("a")))
+	require.NoError(t, s.WriteBatch(context.Background(), shadowTestBatch("b")))
+
+	close(shadow.block)
+	require.NoError(t, s.Close(context.Background()))
+
+	assert.Equal(t, []string{"a", "b"}, primary.writes)
+	assert.Equal(t, []string{"a"}, shadow.writes)
+}
+
+func TestShadowFailures(t *testing.T) {
+	batch := shadowTestBatch("a", "b", "c")
+	index := batch.Index()
+
+	assert.Equal(t, []bool{false, false, false}, shadowFailures(batch, index, nil))
+	assert.Equal(t, []bool{true, true, true}, shadowFailures(batch, index, errors.New("nope")))
+
+	bErr := service.NewBatchError(batch, errors.New("nope")).Failed(1, errors.New("nope"))
+	assert.Equal(t, []bool{false, true, false}, shadowFailures(batch, index, bErr))
+}
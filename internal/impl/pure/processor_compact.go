@@ -0,0 +1,234 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redpanda-data/benthos/v4/public/bloblang"
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	cpFieldKey     = "key"
+	cpFieldOrderBy = "order_by"
+)
+
+func compactProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Compacts a batch so that it only contains the latest message of each key.").
+		Description(`
+For each unique `+"`"+cpFieldKey+"`"+` within a batch only the latest message is kept, and the remaining messages are dropped. The surviving messages retain their original relative order within the batch.
+
+By default the latest message of a key is the last one to appear within the batch. When an `+"`"+cpFieldOrderBy+"`"+` mapping is specified it must result in a number, such as a sequence or a timestamp converted with a method like `+"`ts_unix_nano`"+`, and the message with the highest number is kept instead, where ties are resolved in favour of the message that appears last.
+
+This processor is most useful when combined with a xref:configuration:windowed_processing.adoc[window] or a xref:configuration:batching.adoc[batching policy], as is common when loading changelog streams into stores that only need the current state of each key.
+
+Messages that fail to resolve a key or an order are kept and flagged with an error, which can be handled with xref:configuration:error_handling.adoc[error handling patterns].`).
+		Fields(
+			service.NewInterpolatedStringField(cpFieldKey).
+				Description("The key to compact messages by.").
+				Example(`${! meta("kafka_key") }`).
+				Example(`${! this.id }`),
+			service.NewBloblangField(cpFieldOrderBy).
+				Description("An optional xref:guides:bloblang/about.adoc[Bloblang mapping] that results in a number used for determining the latest message of a key.").
+				Example(`root = this.sequence`).
+				Example(`root = this.updated_at.ts_parse("2006-01-02T15:04:05Z07:00").ts_unix_nano()`).
+				Optional(),
+		).
+		Example("Compact a Changelog", "Within each batch of up to 1000 changes only the most recent update of each row is forwarded.", `
+input:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topics: [ changes ]
+    consumer_group: loader
+    batching:
+      count: 1000
+      period: 5s
+      processors:
+        - compact:
+            key: ${! this.row_id }
+            order_by: root = this.lsn
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"compact", compactProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newCompactProcessorFromConfig(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type compactProcessor struct {
+	key     *service.InterpolatedString
+	orderBy *bloblang.Executor
+}
+
+func newCompactProcessorFromConfig(conf *service.ParsedConfig) (*compactProcessor, error) {
+	c := &compactProcessor{}
+
+	var err error
+	if c.key, err = conf.FieldInterpolatedString(cpFieldKey); err != nil {
+		return nil, err
+	}
+	if conf.Contains(cpFieldOrderBy) {
+		if c.orderBy, err = conf.FieldBloblang(cpFieldOrderBy); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// compactOrderValue normalises an order value into an int64, uint64 or float64
+// so that integers such as nanosecond timestamps are compared without loss of
+// precision.
+func compactOrderValue(v any) (any, error) {
+	switch t := v.(type) {
+	case int:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case int64:
+		return t, nil
+	case uint32:
+		return uint64(t), nil
+	case uint64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case float64:
+		return t, nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return nil, fmt.Errorf("expected order to be a number, got %T", v)
+}
+
+func compactOrderFloat(v any) float64 {
+	switch t := v.(type) {
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case float64:
+		return t
+	}
+	return 0
+}
+
+// compactOrderLess returns whether order a is less than order b, comparing
+// integers natively and only falling back to floats when either is a float.
+func compactOrderLess(a, b any) bool {
+	switch at := a.(type) {
+	case int64:
+		switch bt := b.(type) {
+		case int64:
+			return at < bt
+		case uint64:
+			return at < 0 || uint64(at) < bt
+		}
+	case uint64:
+		switch bt := b.(type) {
+		case uint64:
+			return at < bt
+		case int64:
+			return bt >= 0 && at < uint64(bt)
+		}
+	}
+	return compactOrderFloat(a) < compactOrderFloat(b)
+}
+
+func (c *compactProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	type latest struct {
+		index int
+		order any
+	}
+
+	var orderExec *service.MessageBatchBloblangExecutor
+	if c.orderBy != nil {
+		orderExec = batch.BloblangExecutor(c.orderBy)
+	}
+	keyExec := batch.InterpolationExecutor(c.key)
+
+	keep := make([]bool, len(batch))
+	latests := map[string]latest{}
+	for i, msg := range batch {
+		key, err := keyExec.TryString(i)
+		if err != nil {
+			msg.SetError(fmt.Errorf("key interpolation error: %w", err))
+			keep[i] = true
+			continue
+		}
+
+		var order any = int64(0)
+		if orderExec != nil {
+			res, err := orderExec.Query(i)
+			if err == nil && res == nil {
+				err = errors.New("mapping resulted in a deleted message")
+			}
+			var v any
+			if err == nil {
+				v, err = res.AsStructured()
+			}
+			if err == nil {
+				order, err = compactOrderValue(v)
+			}
+			if err != nil {
+				msg.SetError(fmt.Errorf("order mapping failed: %w", err))
+				keep[i] = true
+				continue
+			}
+		}
+
+		if prev, exists := latests[key]; exists && compactOrderLess(order, prev.order) {
+			continue
+		}
+		latests[key] = latest{index: i, order: order}
+	}
+
+	for _, l := range latests {
+		keep[l.index] = true
+	}
+
+	compacted := make(service.MessageBatch, 0, len(latests))
+	for i, msg := range batch {
+		if keep[i] {
+			compacted = append(compacted, msg)
+		}
+	}
+	if len(compacted) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{compacted}, nil
+}
+
+func (c *compactProcessor) Close(ctx context.Context) error {
+	return nil
+}
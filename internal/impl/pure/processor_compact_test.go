@@ -0,0 +1,140 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestCompactProcessor(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		input  []string
+		output []string
+		errs   []string
+	}{
+		{
+			name: "last in batch wins",
+			conf: `key: ${! this.id }`,
+			input: []string{
+				`{"id":"a","v":1}`,
+				`{"id":"b","v":1}`,
+				`{"id":"a","v":2}`,
+				`{"id":"c","v":1}`,
+				`{"id":"b","v":2}`,
+			},
+			output: []string{
+				`{"id":"a","v":2}`,
+				`{"id":"c","v":1}`,
+				`{"id":"b","v":2}`,
+			},
+		},
+		{
+			name: "ordered by sequence",
+			conf: `
+key: ${! this.id }
+order_by: root = this.seq
+`,
+			input: []string{
+				`{"id":"a","seq":3}`,
+				`{"id":"b","seq":1}`,
+				`{"id":"a","seq":2}`,
+				`{"id":"b","seq":1,"dupe":true}`,
+			},
+			output: []string{
+				`{"id":"a","seq":3}`,
+				`{"id":"b","seq":1,"dupe":true}`,
+			},
+		},
+		{
+			name: "ordered by nanosecond timestamps",
+			conf: `
+key: ${! this.id }
+order_by: root = this.ts.ts_unix_nano()
+`,
+			input: []string{
+				`{"id":"a","ts":"2024-01-01T00:00:00.000000002Z"}`,
+				`{"id":"a","ts":"2024-01-01T00:00:00.000000001Z"}`,
+			},
+			output: []string{
+				`{"id":"a","ts":"2024-01-01T00:00:00.000000002Z"}`,
+			},
+		},
+		{
+			name: "failed orders are kept",
+			conf: `
+key: ${! this.id }
+order_by: root = this.seq
+`,
+			input: []string{
+				`{"id":"a","seq":1}`,
+				`{"id":"a","seq":"nope"}`,
+				`{"id":"a","seq":2}`,
+			},
+			output: []string{
+				`{"id":"a","seq":"nope"}`,
+				`{"id":"a","seq":2}`,
+			},
+			errs: []string{
+				"order mapping failed: expected order to be a number, got string",
+				"",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := compactProcessorConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			proc, err := newCompactProcessorFromConfig(pConf)
+			require.NoError(t, err)
+
+			var batch service.MessageBatch
+			for _, in := range test.input {
+				batch = append(batch, service.NewMessage([]byte(in)))
+			}
+
+			res, err := proc.ProcessBatch(context.Background(), batch)
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+
+			var output, errs []string
+			for _, msg := range res[0] {
+				b, err := msg.AsBytes()
+				require.NoError(t, err)
+				output = append(output, string(b))
+
+				if mErr := msg.GetError(); mErr != nil {
+					errs = append(errs, mErr.Error())
+				} else {
+					errs = append(errs, "")
+				}
+			}
+			assert.Equal(t, test.output, output)
+			if test.errs != nil {
+				assert.Equal(t, test.errs, errs)
+			}
+		})
+	}
+}
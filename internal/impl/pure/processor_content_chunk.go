@@ -0,0 +1,256 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	ccFieldMinSize = "min_size"
+	ccFieldAvgSize = "avg_size"
+	ccFieldMaxSize = "max_size"
+)
+
+func contentChunkProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Splits the payload of each message into content-defined chunks using the FastCDC algorithm.").
+		Description(`
+Unlike splitting a payload into chunks of a fixed size, the boundaries of content-defined chunks are derived from the contents of the payload itself. This means that when a payload is modified, for example by inserting bytes near its start, only the chunks surrounding the modification change, and the remaining chunks (and their hashes) are identical to those of the original payload. This makes it possible to store large, frequently modified objects with deduplication by chunk hash, or to resume transfers by only sending chunks that have not been seen before.
+
+Each message is replaced with a batch containing a message for each chunk, where each chunk is between `+"`"+ccFieldMinSize+"`"+` and `+"`"+ccFieldMaxSize+"`"+` bytes in size, with the exception of the final chunk which may be smaller, and where the sizes of chunks are normalised around `+"`"+ccFieldAvgSize+"`"+`. Chunk boundaries are stable across runs and versions of Redpanda Connect for the same sizes, and therefore changing any of the sizes causes all boundaries to change.
+
+Chunks retain the metadata of the message they were split from, and concatenating the chunks of a message in the order of their index results in the original payload.
+
+== Metadata
+
+This processor adds the following metadata fields to each chunk:
+
+- chunk_index
+- chunk_count
+- chunk_offset
+- chunk_hash
+- chunk_content_hash
+
+The field `+"`chunk_hash`"+` is the hex encoded SHA-256 hash of the chunk, and `+"`chunk_content_hash`"+` is the hex encoded SHA-256 hash of the entire payload that the chunk was split from.`).
+		Fields(
+			service.NewIntField(ccFieldMinSize).
+				Description("The minimum size of a chunk in bytes.").
+				Default(2048).
+				LintRule(`root = if this <= 0 { [ "min_size must be larger than zero" ] }`),
+			service.NewIntField(ccFieldAvgSize).
+				Description("The average size of a chunk in bytes, which is rounded down to the nearest power of two.").
+				Default(8192),
+			service.NewIntField(ccFieldMaxSize).
+				Description("The maximum size of a chunk in bytes.").
+				Default(65536),
+		).
+		Example("Deduplicated Object Storage", "Large files are split into chunks that are stored by their hash, so that chunks shared between files, or between versions of the same file, are only stored once.", `
+input:
+  file:
+    paths: [ ./data/*.bin ]
+    scanner:
+      to_the_end: {}
+
+pipeline:
+  processors:
+    - content_chunk:
+        avg_size: 1048576
+        min_size: 262144
+        max_size: 4194304
+
+output:
+  aws_s3:
+    bucket: chunks
+    path: ${! meta("chunk_hash") }
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"content_chunk", contentChunkProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newContentChunkProcessorFromConfig(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// cdcGear is the table of random values used by the rolling gear hash. It is
+// generated from a fixed seed so that chunk boundaries never change between
+// versions.
+var cdcGear = func() (g [256]uint64) {
+	seed := uint64(0x5cdc5cdc5cdc5cdc)
+	for i := range g {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		g[i] = z ^ (z >> 31)
+	}
+	return
+}()
+
+// cdcMask returns a mask with the n most significant bits set, as the most
+// significant bits of the gear hash are influenced by the widest window of
+// bytes.
+func cdcMask(n int) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+type contentChunkProcessor struct {
+	minSize int
+	avgSize int
+	maxSize int
+
+	// FastCDC normalised chunking uses a harder to match mask before the
+	// average size is reached and an easier one after it.
+	maskS uint64
+	maskL uint64
+}
+
+func newContentChunkProcessorFromConfig(conf *service.ParsedConfig) (*contentChunkProcessor, error) {
+	minSize, err := conf.FieldInt(ccFieldMinSize)
+	if err != nil {
+		return nil, err
+	}
+	avgSize, err := conf.FieldInt(ccFieldAvgSize)
+	if err != nil {
+		return nil, err
+	}
+	maxSize, err := conf.FieldInt(ccFieldMaxSize)
+	if err != nil {
+		return nil, err
+	}
+	return newContentChunkProcessor(minSize, avgSize, maxSize)
+}
+
+func newContentChunkProcessor(minSize, avgSize, maxSize int) (*contentChunkProcessor, error) {
+	if minSize <= 0 {
+		return nil, errors.New("min_size must be larger than zero")
+	}
+	if avgSize < 64 {
+		return nil, fmt.Errorf("avg_size must be at least 64, got %v", avgSize)
+	}
+
+	// The sizes are checked after rounding as the rounded average is used.
+	avgBits := bits.Len(uint(avgSize)) - 1
+	roundedAvgSize := 1 << avgBits
+	if minSize > roundedAvgSize || roundedAvgSize > maxSize {
+		return nil, fmt.Errorf("sizes must satisfy min_size <= avg_size <= max_size once avg_size is rounded down to %v, got %v, %v and %v", roundedAvgSize, minSize, avgSize, maxSize)
+	}
+
+	return &contentChunkProcessor{
+		minSize: minSize,
+		avgSize: roundedAvgSize,
+		maxSize: maxSize,
+		maskS:   cdcMask(avgBits + 2),
+		maskL:   cdcMask(avgBits - 2),
+	}, nil
+}
+
+// cut returns the length of the next chunk at the start of data.
+func (c *contentChunkProcessor) cut(data []byte) int {
+	n := len(data)
+	if n <= c.minSize {
+		return n
+	}
+	if n > c.maxSize {
+		n = c.maxSize
+	}
+	normal := c.avgSize
+	if normal > n {
+		normal = n
+	}
+
+	var h uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		h = (h << 1) + cdcGear[data[i]]
+		if h&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		h = (h << 1) + cdcGear[data[i]]
+		if h&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// chunks splits data into content-defined chunks, there is always at least
+// one chunk even when data is empty.
+func (c *contentChunkProcessor) chunks(data []byte) [][]byte {
+	chunks := [][]byte{}
+	for len(data) > 0 {
+		n := c.cut(data)
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, data)
+	}
+	return chunks
+}
+
+func (c *contentChunkProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	contentHash := sha256.Sum256(data)
+	contentHashStr := hex.EncodeToString(contentHash[:])
+
+	chunks := c.chunks(data)
+	batch := make(service.MessageBatch, 0, len(chunks))
+
+	var offset int
+	for i, chunk := range chunks {
+		chunkHash := sha256.Sum256(chunk)
+
+		cMsg := msg.Copy()
+		cMsg.SetBytes(chunk)
+		cMsg.MetaSetMut("chunk_index", i)
+		cMsg.MetaSetMut("chunk_count", len(chunks))
+		cMsg.MetaSetMut("chunk_offset", offset)
+		cMsg.MetaSetMut("chunk_hash", hex.EncodeToString(chunkHash[:]))
+		cMsg.MetaSetMut("chunk_content_hash", contentHashStr)
+		batch = append(batch, cMsg)
+
+		offset += len(chunk)
+	}
+	return batch, nil
+}
+
+func (c *contentChunkProcessor) Close(ctx context.Context) error {
+	return nil
+}
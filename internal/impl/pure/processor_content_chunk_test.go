@@ -0,0 +1,167 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func randomContentChunkData(seed uint64, n int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(r.Uint32())
+	}
+	return data
+}
+
+func contentChunkHashes(t *testing.T, c *contentChunkProcessor, data []byte) []string {
+	t.Helper()
+
+	batch, err := c.Process(context.Background(), service.NewMessage(data))
+	require.NoError(t, err)
+
+	var hashes []string
+	var joined []byte
+	for i, msg := range batch {
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		joined = append(joined, b...)
+
+		index, _ := msg.MetaGetMut("chunk_index")
+		assert.Equal(t, i, index)
+		count, _ := msg.MetaGetMut("chunk_count")
+		assert.Equal(t, len(batch), count)
+
+		hash, _ := msg.MetaGet("chunk_hash")
+		hashes = append(hashes, hash)
+	}
+	assert.Equal(t, data, joined)
+	return hashes
+}
+
+func TestContentChunkProcessorSizes(t *testing.T) {
+	c, err := newContentChunkProcessor(256, 1024, 4096)
+	require.NoError(t, err)
+
+	data := randomContentChunkData(1, 1<<18)
+	chunks := c.chunks(data)
+	require.Greater(t, len(chunks), 1)
+
+	var total int
+	for i, chunk := range chunks {
+		total += len(chunk)
+		assert.LessOrEqual(t, len(chunk), 4096)
+		if i < len(chunks)-1 {
+			assert.GreaterOrEqual(t, len(chunk), 256)
+		}
+	}
+	assert.Equal(t, len(data), total)
+
+	avg := len(data) / len(chunks)
+	assert.Greater(t, avg, 512)
+	assert.Less(t, avg, 2048)
+}
+
+func TestContentChunkProcessorStableBoundaries(t *testing.T) {
+	c, err := newContentChunkProcessor(256, 1024, 4096)
+	require.NoError(t, err)
+
+	data := randomContentChunkData(2, 1<<17)
+	original := contentChunkHashes(t, c, data)
+
+	modified := append([]byte("an insertion at the start of the payload"), data...)
+	shifted := contentChunkHashes(t, c, modified)
+
+	seen := map[string]struct{}{}
+	for _, h := range original {
+		seen[h] = struct{}{}
+	}
+	var shared int
+	for _, h := range shifted {
+		if _, exists := seen[h]; exists {
+			shared++
+		}
+	}
+	assert.Greater(t, shared, len(original)*9/10)
+}
+
+func TestContentChunkProcessorMetadata(t *testing.T) {
+	c, err := newContentChunkProcessor(64, 64, 64)
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("a"), 150)
+	dataHash := sha256.Sum256(data)
+
+	msg := service.NewMessage(data)
+	msg.MetaSetMut("foo", "bar")
+
+	batch, err := c.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+
+	for i, exp := range []int{0, 64, 128} {
+		offset, _ := batch[i].MetaGetMut("chunk_offset")
+		assert.Equal(t, exp, offset)
+
+		foo, _ := batch[i].MetaGet("foo")
+		assert.Equal(t, "bar", foo)
+
+		contentHash, _ := batch[i].MetaGet("chunk_content_hash")
+		assert.Equal(t, hex.EncodeToString(dataHash[:]), contentHash)
+	}
+}
+
+func TestContentChunkProcessorEmpty(t *testing.T) {
+	c, err := newContentChunkProcessor(256, 1024, 4096)
+	require.NoError(t, err)
+
+	batch, err := c.Process(context.Background(), service.NewMessage(nil))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	count, _ := batch[0].MetaGetMut("chunk_count")
+	assert.Equal(t, 1, count)
+}
+
+func TestContentChunkProcessorBadSizes(t *testing.T) {
+	for _, test := range []struct {
+		name             string
+		minSize, avgSize int
+		maxSize          int
+	}{
+		{name: "zero min", minSize: 0, avgSize: 1024, maxSize: 4096},
+		{name: "small avg", minSize: 16, avgSize: 32, maxSize: 4096},
+		{name: "min above avg", minSize: 2048, avgSize: 1024, maxSize: 4096},
+		{name: "min above rounded avg", minSize: 1500, avgSize: 2000, maxSize: 4096},
+		{name: "avg above max", minSize: 256, avgSize: 8192, maxSize: 4096},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newContentChunkProcessor(test.minSize, test.avgSize, test.maxSize)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	spFieldMode         = "mode"
+	spFieldPercentage   = "percentage"
+	spFieldKey          = "key"
+	spFieldMaxPerSecond = "max_per_second"
+
+	spModePercentage = "percentage"
+	spModeHash       = "hash"
+	spModeRate       = "rate"
+)
+
+func sampleProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Drops a portion of messages in order to produce a downsampled stream.").
+		Description(`
+The sampling strategy is chosen with the field `+"`"+spFieldMode+"`"+`:
+
+- In `+"`percentage`"+` mode each message is kept with a probability of `+"`"+spFieldPercentage+"`"+`.
+- In `+"`hash`"+` mode the interpolated `+"`"+spFieldKey+"`"+` of each message is hashed and the message is kept when the hash falls within the `+"`"+spFieldPercentage+"`"+`, which means the decision is deterministic and all messages sharing a key (a user ID, a trace ID, etc) are either kept or dropped together.
+- In `+"`rate`"+` mode at most `+"`"+spFieldMaxPerSecond+"`"+` messages are kept within each second, and any further messages within that second are dropped.
+
+Messages that are dropped are acknowledged and removed from the pipeline.`).
+		Fields(
+			service.NewStringAnnotatedEnumField(spFieldMode, map[string]string{
+				spModePercentage: "Keep each message with a random probability.",
+				spModeHash:       "Keep messages deterministically based on the hash of a key.",
+				spModeRate:       "Keep a maximum number of messages per second.",
+			}).
+				Description("The sampling strategy to use.").
+				Default(spModePercentage),
+			service.NewFloatField(spFieldPercentage).
+				Description("The percentage of messages to keep, from 0 to 100, used by the `percentage` and `hash` modes.").
+				Example(10).
+				Example(0.5).
+				Default(100.0).
+				LintRule(`root = if this < 0 || this > 100 { ["field must be between 0 and 100"] }`),
+			service.NewInterpolatedStringField(spFieldKey).
+				Description("The key to hash for each message, required by the `hash` mode.").
+				Example(`${! this.user.id }`).
+				Example(`${! meta("trace_id") }`).
+				Optional(),
+			service.NewIntField(spFieldMaxPerSecond).
+				Description("The maximum number of messages to keep within each second, required by the `rate` mode.").
+				Example(1000).
+				Optional(),
+		).
+		Example("Sample by User", "Keep all events of 5% of users, resulting in a stream where the journeys of sampled users are complete.", `
+pipeline:
+  processors:
+    - sample:
+        mode: hash
+        percentage: 5
+        key: ${! this.user_id }
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"sample", sampleProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSampleProcessorFromConfig(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type sampleProcessor struct {
+	mode         string
+	percentage   float64
+	key          *service.InterpolatedString
+	maxPerSecond int
+
+	nowFn      func() time.Time
+	randFn     func() float64
+	rateMut    sync.Mutex
+	rateWindow int64
+	rateCount  int
+}
+
+func newSampleProcessorFromConfig(conf *service.ParsedConfig) (*sampleProcessor, error) {
+	s := &sampleProcessor{
+		nowFn:  time.Now,
+		randFn: rand.Float64,
+	}
+
+	var err error
+	if s.mode, err = conf.FieldString(spFieldMode); err != nil {
+		return nil, err
+	}
+	if s.percentage, err = conf.FieldFloat(spFieldPercentage); err != nil {
+		return nil, err
+	}
+
+	switch s.mode {
+	case spModePercentage:
+	case spModeHash:
+		if !conf.Contains(spFieldKey) {
+			return nil, errors.New("a key must be specified in hash mode")
+		}
+		if s.key, err = conf.FieldInterpolatedString(spFieldKey); err != nil {
+			return nil, err
+		}
+	case spModeRate:
+		if !conf.Contains(spFieldMaxPerSecond) {
+			return nil, errors.New("max_per_second must be specified in rate mode")
+		}
+		if s.maxPerSecond, err = conf.FieldInt(spFieldMaxPerSecond); err != nil {
+			return nil, err
+		}
+		if s.maxPerSecond < 0 {
+			return nil, fmt.Errorf("max_per_second must not be negative, got %v", s.maxPerSecond)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognised mode: %v", s.mode)
+	}
+	return s, nil
+}
+
+func (s *sampleProcessor) keep(msg *service.Message) (bool, error) {
+	switch s.mode {
+	case spModeHash:
+		key, err := s.key.TryString(msg)
+		if err != nil {
+			return false, fmt.Errorf("key interpolation error: %w", err)
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return float64(h.Sum64()%10000) < s.percentage*100, nil
+	case spModeRate:
+		window := s.nowFn().Unix()
+
+		s.rateMut.Lock()
+		defer s.rateMut.Unlock()
+		if window != s.rateWindow {
+			s.rateWindow, s.rateCount = window, 0
+		}
+		if s.rateCount >= s.maxPerSecond {
+			return false, nil
+		}
+		s.rateCount++
+		return true, nil
+	}
+	return s.randFn()*100 < s.percentage, nil
+}
+
+func (s *sampleProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	keep, err := s.keep(msg)
+	if err != nil {
+		return nil, err
+	}
+	if !keep {
+		return nil, nil
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *sampleProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func newSampleProcessorForTest(t *testing.T, conf string) *sampleProcessor {
+	t.Helper()
+
+	pConf, err := sampleProcessorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	s, err := newSampleProcessorFromConfig(pConf)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSampleProcessorPercentage(t *testing.T) {
+	s := newSampleProcessorForTest(t, `
+percentage: 25
+`)
+
+	rolls := []float64{0.1, 0.24, 0.25, 0.9}
+	s.randFn = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	var kept int
+	for i := 0; i < 4; i++ {
+		batch, err := s.Process(context.Background(), service.NewMessage([]byte("hello")))
+		require.NoError(t, err)
+		kept += len(batch)
+	}
+	assert.Equal(t, 2, kept)
+}
+
+func TestSampleProcessorHash(t *testing.T) {
+	s := newSampleProcessorForTest(t, `
+mode: hash
+percentage: 50
+key: ${! this.id }
+`)
+
+	decisions := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("user-%v", i%20)
+		batch, err := s.Process(context.Background(), service.NewMessage([]byte(`{"id":"`+id+`"}`)))
+		require.NoError(t, err)
+
+		kept := len(batch) == 1
+		if prev, exists := decisions[id]; exists {
+			assert.Equal(t, prev, kept, "decision for %v should be deterministic", id)
+		}
+		decisions[id] = kept
+	}
+
+	var kept int
+	for _, k := range decisions {
+		if k {
+			kept++
+		}
+	}
+	assert.Greater(t, kept, 0)
+	assert.Less(t, kept, 20)
+
+	_, err := s.Process(context.Background(), service.NewMessage([]byte(`not json`)))
+	require.Error(t, err)
+}
+
+func TestSampleProcessorRate(t *testing.T) {
+	s := newSampleProcessorForTest(t, `
+mode: rate
+max_per_second: 3
+`)
+
+	now := time.Unix(1000, 0)
+	s.nowFn = func() time.Time { return now }
+
+	process := func(n int) (kept int) {
+		for i := 0; i < n; i++ {
+			batch, err := s.Process(context.Background(), service.NewMessage([]byte("hello")))
+			require.NoError(t, err)
+			kept += len(batch)
+		}
+		return
+	}
+
+	assert.Equal(t, 3, process(10))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 0, process(5))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 3, process(5))
+}
+
+func TestSampleProcessorConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`mode: hash`,
+		`mode: rate`,
+		`{ mode: rate, max_per_second: -1 }`,
+	} {
+		pConf, err := sampleProcessorConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newSampleProcessorFromConfig(pConf)
+		require.Error(t, err, conf)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redpanda-data/benthos/v4/public/bloblang"
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	smFieldVersion           = "version"
+	smFieldMigrations        = "migrations"
+	smFieldMigrationsFrom    = "from"
+	smFieldMigrationsTo      = "to"
+	smFieldMigrationsMapping = "mapping"
+)
+
+func schemaMigrationProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping").
+		Version("4.40.0").
+		Summary("Upgrades messages from older schema versions to the latest version by executing a chain of versioned Bloblang migrations.").
+		Description(`
+The `+"`"+smFieldVersion+"`"+` mapping is executed against each message in order to resolve its current schema version, which could be a field of the document or, for example, the schema ID extracted by a `+"`schema_registry_decode`"+` processor. Starting from that version the migration whose `+"`"+smFieldMigrationsFrom+"`"+` matches is executed, followed by the migration matching its `+"`"+smFieldMigrationsTo+"`"+` version, and so on until the latest version is reached.
+
+The migrations must form a single chain without cycles, and the latest version is the `+"`"+smFieldMigrationsTo+"`"+` version of the final migration of that chain. Messages that are already at the latest version pass through unchanged, and messages with a version that is not part of the chain fail and can be handled with xref:configuration:error_handling.adoc[error handling patterns].
+
+Migrations are responsible for updating the version of the document itself where it is stored within the document, this processor does not modify the message other than by executing the migrations.`).
+		Fields(
+			service.NewBloblangField(smFieldVersion).
+				Description("A xref:guides:bloblang/about.adoc[Bloblang mapping] that resolves the current schema version of each message.").
+				Example(`root = this.schema_version`).
+				Example(`root = meta("schema_id")`),
+			service.NewObjectListField(smFieldMigrations,
+				service.NewStringField(smFieldMigrationsFrom).
+					Description("The version that this migration upgrades messages from."),
+				service.NewStringField(smFieldMigrationsTo).
+					Description("The version that this migration upgrades messages to."),
+				service.NewBloblangField(smFieldMigrationsMapping).
+					Description("A xref:guides:bloblang/about.adoc[Bloblang mapping] that upgrades a message from the `from` version to the `to` version."),
+			).Description("The list of migrations between schema versions."),
+		).
+		Example("Versioned Documents", "Documents carry their version in the field `v`, and over time a `name` field was split into `first_name` and `last_name`, and later `age` was renamed to `years`.", `
+pipeline:
+  processors:
+    - schema_migration:
+        version: root = this.v.or(1).string()
+        migrations:
+          - from: "1"
+            to: "2"
+            mapping: |
+              root = this.without("name")
+              root.first_name = this.name.split(" ").index(0)
+              root.last_name = this.name.split(" ").slice(1).join(" ")
+              root.v = 2
+          - from: "2"
+            to: "3"
+            mapping: |
+              root = this.without("age")
+              root.years = this.age
+              root.v = 3
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_migration", schemaMigrationProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaMigrationProcessorFromConfig(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type schemaMigration struct {
+	to      string
+	mapping *bloblang.Executor
+}
+
+type schemaMigrationProcessor struct {
+	version    *bloblang.Executor
+	migrations map[string]schemaMigration
+	latest     string
+}
+
+func newSchemaMigrationProcessorFromConfig(conf *service.ParsedConfig) (*schemaMigrationProcessor, error) {
+	version, err := conf.FieldBloblang(smFieldVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	mConfs, err := conf.FieldObjectList(smFieldMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[string]schemaMigration{}
+	var froms []string
+	for i, mConf := range mConfs {
+		from, err := mConf.FieldString(smFieldMigrationsFrom)
+		if err != nil {
+			return nil, err
+		}
+		var m schemaMigration
+		if m.to, err = mConf.FieldString(smFieldMigrationsTo); err != nil {
+			return nil, err
+		}
+		if m.mapping, err = mConf.FieldBloblang(smFieldMigrationsMapping); err != nil {
+			return nil, err
+		}
+		if _, exists := migrations[from]; exists {
+			return nil, fmt.Errorf("migration %v: duplicate migration from version %v", i, from)
+		}
+		migrations[from] = m
+		froms = append(froms, from)
+	}
+	return newSchemaMigrationProcessor(version, migrations, froms)
+}
+
+func newSchemaMigrationProcessor(version *bloblang.Executor, migrations map[string]schemaMigration, froms []string) (*schemaMigrationProcessor, error) {
+	if len(migrations) == 0 {
+		return nil, errors.New("at least one migration must be specified")
+	}
+
+	// There must be exactly one version that no migration upgrades to, which
+	// is the start of the chain.
+	targets := map[string]struct{}{}
+	for _, m := range migrations {
+		targets[m.to] = struct{}{}
+	}
+	var start string
+	var starts int
+	for _, from := range froms {
+		if _, exists := targets[from]; !exists {
+			start = from
+			starts++
+		}
+	}
+	if starts != 1 {
+		return nil, errors.New("migrations must form a single chain without cycles")
+	}
+
+	// Walk the chain in order to verify that every migration is reachable and
+	// to find the latest version.
+	latest, visited := start, 0
+	for {
+		m, exists := migrations[latest]
+		if !exists {
+			break
+		}
+		if visited++; visited > len(migrations) {
+			return nil, errors.New("migrations must form a single chain without cycles")
+		}
+		latest = m.to
+	}
+	if visited != len(migrations) {
+		return nil, errors.New("migrations must form a single chain without cycles")
+	}
+
+	return &schemaMigrationProcessor{
+		version:    version,
+		migrations: migrations,
+		latest:     latest,
+	}, nil
+}
+
+func (s *schemaMigrationProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	vMsg, err := msg.BloblangQuery(s.version)
+	if err != nil {
+		return nil, fmt.Errorf("version mapping failed: %w", err)
+	}
+	if vMsg == nil {
+		return nil, errors.New("version mapping resulted in a deleted message")
+	}
+	vBytes, err := vMsg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	version := string(vBytes)
+	for version != s.latest {
+		m, exists := s.migrations[version]
+		if !exists {
+			return nil, fmt.Errorf("unrecognised schema version: %v", version)
+		}
+		if msg, err = msg.BloblangQuery(m.mapping); err != nil {
+			return nil, fmt.Errorf("migration from version %v to %v failed: %w", version, m.to, err)
+		}
+		if msg == nil {
+			return nil, nil
+		}
+		version = m.to
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *schemaMigrationProcessor) Close(ctx context.Context) error {
+	return nil
+}
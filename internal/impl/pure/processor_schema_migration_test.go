@@ -0,0 +1,116 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestSchemaMigrationProcessor(t *testing.T) {
+	pConf, err := schemaMigrationProcessorConfig().ParseYAML(`
+version: root = this.v
+migrations:
+  - from: "2"
+    to: "3"
+    mapping: |
+      root = this.without("age")
+      root.years = this.age
+      root.v = 3
+  - from: "1"
+    to: "2"
+    mapping: |
+      root = this
+      root.age = this.age.number()
+      root.v = 2
+`, nil)
+	require.NoError(t, err)
+
+	p, err := newSchemaMigrationProcessorFromConfig(pConf)
+	require.NoError(t, err)
+	assert.Equal(t, "3", p.latest)
+
+	tests := []struct {
+		input       string
+		output      string
+		errContains string
+	}{
+		{input: `{"v":1,"age":"20"}`, output: `{"v":3,"years":20}`},
+		{input: `{"v":2,"age":20}`, output: `{"v":3,"years":20}`},
+		{input: `{"v":3,"years":20}`, output: `{"v":3,"years":20}`},
+		{input: `{"v":4}`, errContains: "unrecognised schema version: 4"},
+		{input: `{"v":1,"age":"nope"}`, errContains: "migration from version 1 to 2 failed"},
+	}
+
+	for _, test := range tests {
+		batch, err := p.Process(context.Background(), service.NewMessage([]byte(test.input)))
+		if test.errContains != "" {
+			require.Error(t, err, test.input)
+			assert.Contains(t, err.Error(), test.errContains)
+			continue
+		}
+		require.NoError(t, err, test.input)
+		require.Len(t, batch, 1)
+
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.JSONEq(t, test.output, string(b), test.input)
+	}
+}
+
+func TestSchemaMigrationProcessorBadChains(t *testing.T) {
+	for _, conf := range []string{
+		`
+version: root = this.v
+migrations: []
+`,
+		`
+version: root = this.v
+migrations:
+  - { from: "1", to: "2", mapping: "root = this" }
+  - { from: "2", to: "1", mapping: "root = this" }
+`,
+		`
+version: root = this.v
+migrations:
+  - { from: "1", to: "2", mapping: "root = this" }
+  - { from: "3", to: "4", mapping: "root = this" }
+`,
+		`
+version: root = this.v
+migrations:
+  - { from: "1", to: "2", mapping: "root = this" }
+  - { from: "2", to: "3", mapping: "root = this" }
+  - { from: "3", to: "2", mapping: "root = this" }
+`,
+		`
+version: root = this.v
+migrations:
+  - { from: "1", to: "2", mapping: "root = this" }
+  - { from: "1", to: "3", mapping: "root = this" }
+`,
+	} {
+		pConf, err := schemaMigrationProcessorConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newSchemaMigrationProcessorFromConfig(pConf)
+		require.Error(t, err, conf)
+	}
+}
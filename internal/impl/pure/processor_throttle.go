@@ -0,0 +1,319 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+const (
+	tpFieldKey       = "key"
+	tpFieldCount     = "count"
+	tpFieldInterval  = "interval"
+	tpFieldAction    = "action"
+	tpFieldCache     = "cache"
+	tpFieldRateLimit = "rate_limit"
+
+	tpActionDrop  = "drop"
+	tpActionDefer = "defer"
+)
+
+func throttleProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.40.0").
+		Summary("Limits the number of messages that pass through within an interval for each value of an interpolated key.").
+		Description(`
+Time is divided into fixed windows of length `+"`"+tpFieldInterval+"`"+`, and within each window at most `+"`"+tpFieldCount+"`"+` messages are allowed for each unique key. Messages that exceed the limit of their key are either dropped, or held until the next window begins in which case they are reattempted, depending on the field `+"`"+tpFieldAction+"`"+`.
+
+Alternatively, a xref:components:rate_limits/about.adoc[rate limit resource] can be specified with `+"`"+tpFieldRateLimit+"`"+` instead of a `+"`"+tpFieldKey+"`"+`, in which case messages are throttled by that resource rather than by `+"`"+tpFieldCount+"`"+` and `+"`"+tpFieldInterval+"`"+`. The field can be interpolated in order to select a different resource for each message, e.g. for each tier of customer, and resources such as `+"`redis`"+` share their limit across instances of Redpanda Connect. Messages that are refused by the resource are either dropped, or held for the period the resource asks to wait and reattempted.
+
+By default the counters of each key are held in memory and are therefore local to the processor. In order to share limits across multiple processors or instances of Redpanda Connect a `+"`"+tpFieldCache+"`"+` resource can be specified instead, in which case counters are stored within it with a TTL of the interval. Each allowed message claims a slot of its key by adding a key to the cache, which fails when the slot is already claimed, and therefore the limit is exact across instances as long as the add operation of the cache is atomic, as is the case for caches such as `+"`redis`"+` and `+"`memcached`"+`.
+
+== Metrics
+
+This processor emits the counter `+"`throttle_decision`"+`, labelled with the `+"`decision`"+` made for each message, which is one of `+"`allowed`, `dropped` or `deferred`"+`.`).
+		Fields(
+			service.NewInterpolatedStringField(tpFieldKey).
+				Description("The key to limit messages by, messages sharing a key share the same limit. Either this field or `rate_limit` must be set.").
+				Example(`${! this.user.id }`).
+				Example(`${! meta("kafka_key") }`).
+				Optional(),
+			service.NewIntField(tpFieldCount).
+				Description("The maximum number of messages to allow for each key within an interval.").
+				Default(1000).
+				LintRule(`root = if this <= 0 { [ "count must be larger than zero" ] }`),
+			service.NewDurationField(tpFieldInterval).
+				Description("The length of each time window.").
+				Default("1s"),
+			service.NewStringAnnotatedEnumField(tpFieldAction, map[string]string{
+				tpActionDrop:  "Drop messages that exceed the limit of their key.",
+				tpActionDefer: "Block until the next window begins and reattempt the message, applying back pressure.",
+			}).
+				Description("What to do with messages that exceed the limit of their key.").
+				Default(tpActionDrop),
+			service.NewStringField(tpFieldCache).
+				Description("An optional xref:components:caches/about.adoc[cache resource] used for storing the counters of each key, allowing limits to be shared.").
+				Optional().
+				Advanced(),
+			service.NewInterpolatedStringField(tpFieldRateLimit).
+				Description("The name of a xref:components:rate_limits/about.adoc[rate limit resource] to throttle messages by, instead of limiting them by `key`.").
+				Example("shared_limit").
+				Example(`${! meta("tier") }_limit`).
+				Optional(),
+		).
+		Example("Limit per User", "Allow at most ten events per user per minute, dropping the rest.", `
+pipeline:
+  processors:
+    - throttle:
+        key: ${! this.user_id }
+        count: 10
+        interval: 1m
+`).
+		Example("Shared Rate Limit", "Defer messages according to a rate limit resource selected by the tier of the customer, where each resource is shared by all instances through Redis.", `
+pipeline:
+  processors:
+    - throttle:
+        rate_limit: ${! this.tier }_limit
+        action: defer
+
+rate_limit_resources:
+  - label: free_limit
+    redis:
+      url: redis://localhost:6379
+      key: free
+      count: 100
+      interval: 1m
+  - label: paid_limit
+    redis:
+      url: redis://localhost:6379
+      key: paid
+      count: 10000
+      interval: 1m
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"throttle", throttleProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newThrottleProcessorFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type throttleProcessor struct {
+	key       *service.InterpolatedString
+	rateLimit *service.InterpolatedString
+	count     int
+	interval  time.Duration
+	action    string
+	cache     string
+
+	mgr       *service.Resources
+	mDecision *service.MetricCounter
+	nowFn     func() time.Time
+
+	countersMut sync.Mutex
+	window      int64
+	counters    map[string]int
+}
+
+func newThrottleProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*throttleProcessor, error) {
+	t := &throttleProcessor{
+		mgr:       mgr,
+		mDecision: mgr.Metrics().NewCounter("throttle_decision", "decision"),
+		nowFn:     time.Now,
+		counters:  map[string]int{},
+	}
+
+	var err error
+	if conf.Contains(tpFieldKey) {
+		if t.key, err = conf.FieldInterpolatedString(tpFieldKey); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(tpFieldRateLimit) {
+		if t.rateLimit, err = conf.FieldInterpolatedString(tpFieldRateLimit); err != nil {
+			return nil, err
+		}
+	}
+	if (t.key == nil) == (t.rateLimit == nil) {
+		return nil, fmt.Errorf("exactly one of %v and %v must be set", tpFieldKey, tpFieldRateLimit)
+	}
+	if t.count, err = conf.FieldInt(tpFieldCount); err != nil {
+		return nil, err
+	}
+	if t.count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if t.interval, err = conf.FieldDuration(tpFieldInterval); err != nil {
+		return nil, err
+	}
+	if t.interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	if t.action, err = conf.FieldString(tpFieldAction); err != nil {
+		return nil, err
+	}
+	if conf.Contains(tpFieldCache) {
+		if t.cache, err = conf.FieldString(tpFieldCache); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(t.cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", t.cache)
+		}
+		if t.rateLimit != nil {
+			return nil, fmt.Errorf("%v cannot be used with %v", tpFieldCache, tpFieldRateLimit)
+		}
+	}
+	return t, nil
+}
+
+// allow attempts to count a message against the limit of a key, returning
+// whether it is allowed and, when it isn't, how long until the next window.
+func (t *throttleProcessor) allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	now := t.nowFn()
+	window := now.UnixNano() / int64(t.interval)
+	untilNext := time.Duration((window+1)*int64(t.interval) - now.UnixNano())
+
+	if t.cache != "" {
+		allowed, err := t.allowCached(ctx, key, window)
+		return allowed, untilNext, err
+	}
+
+	t.countersMut.Lock()
+	defer t.countersMut.Unlock()
+
+	if window != t.window {
+		t.window = window
+		t.counters = map[string]int{}
+	}
+	if t.counters[key] >= t.count {
+		return false, untilNext, nil
+	}
+	t.counters[key]++
+	return true, untilNext, nil
+}
+
+// allowCached claims one of the slots of a key within a window by adding a
+// cache key for the slot, which fails when another processor has already
+// claimed it. The number of claimed slots is also stored in order to skip the
+// slots that are already taken.
+func (t *throttleProcessor) allowCached(ctx context.Context, key string, window int64) (allowed bool, err error) {
+	windowKey := key + ":" + strconv.FormatInt(window, 10)
+	if cErr := t.mgr.AccessCache(ctx, t.cache, func(c service.Cache) {
+		var slot int
+		var countBytes []byte
+		if countBytes, err = c.Get(ctx, windowKey); err == nil {
+			if slot, err = strconv.Atoi(string(countBytes)); err != nil {
+				err = fmt.Errorf("failed to parse counter: %w", err)
+				return
+			}
+		} else if !errors.Is(err, service.ErrKeyNotFound) {
+			return
+		}
+		err = nil
+
+		for ; slot < t.count; slot++ {
+			slotKey := windowKey + ":" + strconv.Itoa(slot)
+			if err = c.Add(ctx, slotKey, []byte("1"), &t.interval); err != nil {
+				if errors.Is(err, service.ErrKeyAlreadyExists) {
+					err = nil
+					continue
+				}
+				return
+			}
+			allowed = true
+			err = c.Set(ctx, windowKey, []byte(strconv.Itoa(slot+1)), &t.interval)
+			return
+		}
+	}); cErr != nil {
+		return false, cErr
+	}
+	return
+}
+
+// allowRateLimited accesses a rate limit resource, returning whether a message
+// is allowed and, when it isn't, how long the resource asks to wait.
+func (t *throttleProcessor) allowRateLimited(ctx context.Context, name string) (bool, time.Duration, error) {
+	var wait time.Duration
+	var err error
+	if rErr := t.mgr.AccessRateLimit(ctx, name, func(rl service.RateLimit) {
+		wait, err = rl.Access(ctx)
+	}); rErr != nil {
+		return false, 0, rErr
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return wait <= 0, wait, nil
+}
+
+func (t *throttleProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	var key, rateLimit string
+	var err error
+	if t.rateLimit != nil {
+		if rateLimit, err = t.rateLimit.TryString(msg); err != nil {
+			return nil, fmt.Errorf("rate limit interpolation error: %w", err)
+		}
+	} else if key, err = t.key.TryString(msg); err != nil {
+		return nil, fmt.Errorf("key interpolation error: %w", err)
+	}
+
+	for {
+		var allowed bool
+		var untilNext time.Duration
+		if t.rateLimit != nil {
+			allowed, untilNext, err = t.allowRateLimited(ctx, rateLimit)
+		} else {
+			allowed, untilNext, err = t.allow(ctx, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			t.mDecision.Incr(1, "allowed")
+			return service.MessageBatch{msg}, nil
+		}
+		if t.action == tpActionDrop {
+			t.mDecision.Incr(1, "dropped")
+			return nil, nil
+		}
+
+		t.mDecision.Incr(1, "deferred")
+		select {
+		case <-time.After(untilNext):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (t *throttleProcessor) Close(ctx context.Context) error {
+	return nil
+}
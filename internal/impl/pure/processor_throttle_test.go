@@ -0,0 +1,247 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func TestThrottleProcessorDrop(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		conf string
+		mgr  *service.Resources
+	}{
+		{
+			name: "local",
+			conf: `
+key: ${! meta("user") }
+count: 2
+interval: 1m
+`,
+			mgr: service.MockResources(),
+		},
+		{
+			name: "cache",
+			conf: `
+key: ${! meta("user") }
+count: 2
+interval: 1m
+cache: foo
+`,
+			mgr: service.MockResources(service.MockResourcesOptAddCache("foo")),
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := throttleProcessorConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			p, err := newThrottleProcessorFromConfig(pConf, test.mgr)
+			require.NoError(t, err)
+
+			now := time.Unix(600, 0)
+			p.nowFn = func() time.Time { return now }
+
+			process := func(user string, n int) (kept int) {
+				for i := 0; i < n; i++ {
+					msg := service.NewMessage([]byte("hello"))
+					msg.MetaSetMut("user", user)
+					batch, err := p.Process(context.Background(), msg)
+					require.NoError(t, err)
+					kept += len(batch)
+				}
+				return
+			}
+
+			assert.Equal(t, 2, process("a", 5))
+			assert.Equal(t, 2, process("b", 5))
+			assert.Equal(t, 0, process("a", 5))
+
+			now = now.Add(time.Minute)
+			assert.Equal(t, 2, process("a", 5))
+		})
+	}
+}
+
+func TestThrottleProcessorCacheSlots(t *testing.T) {
+	pConf, err := throttleProcessorConfig().ParseYAML(`
+key: ${! meta("user") }
+count: 2
+interval: 1m
+cache: foo
+`, nil)
+	require.NoError(t, err)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foo"))
+	p, err := newThrottleProcessorFromConfig(pConf, mgr)
+	require.NoError(t, err)
+
+	now := time.Unix(600, 0)
+	p.nowFn = func() time.Time { return now }
+
+	process := func(n int) (kept int) {
+		for i := 0; i < n; i++ {
+			msg := service.NewMessage([]byte("hello"))
+			msg.MetaSetMut("user", "a")
+			batch, err := p.Process(context.Background(), msg)
+			require.NoError(t, err)
+			kept += len(batch)
+		}
+		return
+	}
+
+	assert.Equal(t, 2, process(5))
+
+	// Losing the counter, as would happen when instances race, does not
+	// allow more messages as the slots are already claimed.
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c service.Cache) {
+		require.NoError(t, c.Delete(context.Background(), "a:10"))
+	}))
+	assert.Equal(t, 0, process(5))
+}
+
+func TestThrottleProcessorDefer(t *testing.T) {
+	pConf, err := throttleProcessorConfig().ParseYAML(`
+key: foo
+count: 1
+interval: 50ms
+action: defer
+`, nil)
+	require.NoError(t, err)
+
+	p, err := newThrottleProcessorFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		batch, err := p.Process(ctx, service.NewMessage([]byte("hello")))
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.Process(cancelledCtx, service.NewMessage([]byte("hello")))
+	if err == nil {
+		// The window may have rolled over, in which case the message is
+		// allowed immediately, so try again within the same window.
+		_, err = p.Process(cancelledCtx, service.NewMessage([]byte("hello")))
+	}
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestThrottleProcessorRateLimit(t *testing.T) {
+	pConf, err := throttleProcessorConfig().ParseYAML(`
+rate_limit: ${! meta("tier") }
+`, nil)
+	require.NoError(t, err)
+
+	limitFn := func(limit int) func(context.Context) (time.Duration, error) {
+		var accessed int
+		return func(context.Context) (time.Duration, error) {
+			if accessed++; accessed > limit {
+				return time.Minute, nil
+			}
+			return 0, nil
+		}
+	}
+
+	mgr := service.MockResources(
+		service.MockResourcesOptAddRateLimit("free", limitFn(1)),
+		service.MockResourcesOptAddRateLimit("paid", limitFn(3)),
+	)
+	p, err := newThrottleProcessorFromConfig(pConf, mgr)
+	require.NoError(t, err)
+
+	process := func(tier string, n int) (kept int) {
+		for i := 0; i < n; i++ {
+			msg := service.NewMessage([]byte("hello"))
+			msg.MetaSetMut("tier", tier)
+			batch, err := p.Process(context.Background(), msg)
+			require.NoError(t, err)
+			kept += len(batch)
+		}
+		return
+	}
+
+	assert.Equal(t, 1, process("free", 5))
+	assert.Equal(t, 3, process("paid", 5))
+
+	msg := service.NewMessage([]byte("hello"))
+	msg.MetaSetMut("tier", "missing")
+	_, err = p.Process(context.Background(), msg)
+	require.Error(t, err)
+}
+
+func TestThrottleProcessorRateLimitDefer(t *testing.T) {
+	pConf, err := throttleProcessorConfig().ParseYAML(`
+rate_limit: foo
+action: defer
+`, nil)
+	require.NoError(t, err)
+
+	var accessed int
+	mgr := service.MockResources(service.MockResourcesOptAddRateLimit("foo", func(context.Context) (time.Duration, error) {
+		if accessed++; accessed%2 == 0 {
+			return 10 * time.Millisecond, nil
+		}
+		return 0, nil
+	}))
+	p, err := newThrottleProcessorFromConfig(pConf, mgr)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	for i := 0; i < 3; i++ {
+		batch, err := p.Process(ctx, service.NewMessage([]byte("hello")))
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+	}
+	assert.Equal(t, 5, accessed)
+}
+
+func TestThrottleProcessorConfigErrors(t *testing.T) {
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foo"))
+	for _, conf := range []string{
+		`count: 10`,
+		`
+key: foo
+rate_limit: bar
+`,
+		`
+rate_limit: bar
+cache: foo
+`,
+	} {
+		pConf, err := throttleProcessorConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newThrottleProcessorFromConfig(pConf, mgr)
+		assert.Error(t, err, conf)
+	}
+}
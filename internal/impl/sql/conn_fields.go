@@ -133,6 +133,17 @@ CREATE TABLE IF NOT EXISTS some_table (
 	}
 }
 
+func queryFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewDurationField("query_timeout").
+			Description("An optional maximum period of time to wait for each query to complete, after which the query is cancelled and fails.").
+			Example("30s").
+			Optional().
+			Advanced().
+			Version("4.40.0"),
+	}
+}
+
 func rawQueryField() *service.ConfigField {
 	return service.NewStringField("query").
 		Description("The query to execute. The style of placeholder to use depends on the driver, some drivers require question marks (`?`) whereas others expect incrementing dollar signs (`$1`, `$2`, and so on) or colons (`:1`, `:2` and so on). The style to use is outlined in this table:" + `
@@ -156,6 +167,7 @@ type connSettings struct {
 	connMaxIdleTime time.Duration
 	maxIdleConns    int
 	maxOpenConns    int
+	queryTimeout    time.Duration
 
 	initOnce           sync.Once
 	initFileStatements [][2]string // (path,statement)
@@ -186,6 +198,15 @@ func (c *connSettings) apply(ctx context.Context, db *sql.DB, log *service.Logge
 	})
 }
 
+// queryContext returns a context for a single query, which is cancelled once
+// query_timeout has elapsed when it is set.
+func (c *connSettings) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.queryTimeout)
+}
+
 func connSettingsFromParsed(
 	conf *service.ParsedConfig,
 	mgr *service.Resources,
@@ -216,6 +237,12 @@ func connSettingsFromParsed(
 		}
 	}
 
+	if conf.Contains("query_timeout") {
+		if c.queryTimeout, err = conf.FieldDuration("query_timeout"); err != nil {
+			return
+		}
+	}
+
 	if conf.Contains("init_statement") {
 		if c.initStatement, err = conf.FieldString("init_statement"); err != nil {
 			return
@@ -0,0 +1,105 @@
+// Copyright 2024 Redpanda Data, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redpanda-data/benthos/v4/public/service"
+)
+
+func newSQLOutboxInputForTest(t *testing.T, conf string) *sqlOutboxInput {
+	t.Helper()
+
+	pConf, err := sqlOutboxInputConfig().ParseYAML(conf, service.NewEnvironment())
+	require.NoError(t, err)
+
+	i, err := newSQLOutboxInputFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return i
+}
+
+func TestSQLOutboxInputQueries(t *testing.T) {
+	tests := []struct {
+		name          string
+		conf          string
+		selectQuery   string
+		ids           []any
+		completeQuery string
+	}{
+		{
+			name: "postgres delete",
+			conf: `
+driver: postgres
+dsn: woof
+table: outbox
+`,
+			selectQuery:   "SELECT * FROM outbox ORDER BY id LIMIT 100 FOR UPDATE SKIP LOCKED",
+			ids:           []any{1, 2},
+			completeQuery: "DELETE FROM outbox WHERE id IN ($1,$2)",
+		},
+		{
+			name: "mysql mark",
+			conf: `
+driver: mysql
+dsn: woof
+table: outbox
+id_column: seq
+columns: [ seq, payload ]
+processed_column: processed_at
+limit: 10
+`,
+			selectQuery:   "SELECT seq, payload FROM outbox WHERE processed_at IS NULL ORDER BY seq LIMIT 10 FOR UPDATE SKIP LOCKED",
+			ids:           []any{"a", "b", "c"},
+			completeQuery: "UPDATE outbox SET processed_at = CURRENT_TIMESTAMP WHERE seq IN (?,?,?)",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			i := newSQLOutboxInputForTest(t, test.conf)
+			t.Cleanup(func() {
+				require.NoError(t, i.Close(context.Background()))
+			})
+
+			query, _, err := i.selectBuilder.ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, test.selectQuery, query)
+
+			query, args, err := i.completeQuery(test.ids).ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, test.completeQuery, query)
+			assert.Equal(t, test.ids, args)
+		})
+	}
+}
+
+func TestSQLOutboxInputBadLimit(t *testing.T) {
+	pConf, err := sqlOutboxInputConfig().ParseYAML(`
+driver: postgres
+dsn: woof
+table: outbox
+limit: 0
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	_, err = newSQLOutboxInputFromConfig(pConf, service.MockResources())
+	require.Error(t, err)
+}
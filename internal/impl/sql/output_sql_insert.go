@@ -59,6 +59,9 @@ func sqlInsertOutputConfig() *service.ConfigSpec {
 			Description("The maximum number of inserts to run in parallel.").
 			Default(64))
 
+	for _, f := range queryFields() {
+		spec = spec.Field(f)
+	}
 	for _, f := range connFields() {
 		spec = spec.Field(f)
 	}
@@ -235,13 +238,16 @@ func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageB
 	s.dbMut.RLock()
 	defer s.dbMut.RUnlock()
 
+	ctx, done := s.connSettings.queryContext(ctx)
+	defer done()
+
 	insertBuilder := s.builder
 
 	var tx *sql.Tx
 	var stmt *sql.Stmt
 	if s.useTxStmt {
 		var err error
-		if tx, err = s.db.Begin(); err != nil {
+		if tx, err = s.db.BeginTx(ctx, nil); err != nil {
 			return err
 		}
 		sqlStr, _, err := insertBuilder.ToSql()
@@ -280,7 +286,7 @@ func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageB
 
 		if tx == nil {
 			insertBuilder = insertBuilder.Values(args...)
-		} else if _, err := stmt.Exec(args...); err != nil {
+		} else if _, err := stmt.ExecContext(ctx, args...); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
@@ -49,6 +49,9 @@ func sqlRawOutputConfig() *service.ConfigSpec {
 			Description("The maximum number of inserts to run in parallel.").
 			Default(64))
 
+	for _, f := range queryFields() {
+		spec = spec.Field(f)
+	}
 	for _, f := range connFields() {
 		spec = spec.Field(f)
 	}
@@ -245,13 +248,21 @@ func (s *sqlRawOutput) WriteBatch(ctx context.Context, batch service.MessageBatc
 			}
 		}
 
-		if _, err := s.db.ExecContext(ctx, queryStr, args...); err != nil {
+		if err := s.exec(ctx, queryStr, args); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (s *sqlRawOutput) exec(ctx context.Context, queryStr string, args []any) error {
+	ctx, done := s.connSettings.queryContext(ctx)
+	defer done()
+
+	_, err := s.db.ExecContext(ctx, queryStr, args...)
+	return err
+}
+
 func (s *sqlRawOutput) Close(ctx context.Context) error {
 	s.shutSig.TriggerHardStop()
 	s.dbMut.RLock()
@@ -58,6 +58,9 @@ If the insert fails to execute then the message will still remain unchanged and
 			Advanced().
 			Example("ON CONFLICT (name) DO NOTHING"))
 
+	for _, f := range queryFields() {
+		spec = spec.Field(f)
+	}
 	for _, f := range connFields() {
 		spec = spec.Field(f)
 	}
@@ -106,6 +109,7 @@ type sqlInsertProcessor struct {
 	useTxStmt     bool
 	argsMapping   *bloblang.Executor
 	argsConverter argsConverter
+	connSettings  *connSettings
 
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
@@ -187,8 +191,7 @@ func NewSQLInsertProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Re
 		s.builder = s.builder.Suffix(suffixStr)
 	}
 
-	connSettings, err := connSettingsFromParsed(conf, mgr)
-	if err != nil {
+	if s.connSettings, err = connSettingsFromParsed(conf, mgr); err != nil {
 		return nil, err
 	}
 
@@ -196,7 +199,7 @@ func NewSQLInsertProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Re
 		return nil, err
 	}
 
-	connSettings.apply(context.Background(), s.db, s.logger)
+	s.connSettings.apply(context.Background(), s.db, s.logger)
 
 	go func() {
 		<-s.shutSig.HardStopChan()
@@ -214,13 +217,16 @@ func (s *sqlInsertProcessor) ProcessBatch(ctx context.Context, batch service.Mes
 	s.dbMut.RLock()
 	defer s.dbMut.RUnlock()
 
+	ctx, done := s.connSettings.queryContext(ctx)
+	defer done()
+
 	insertBuilder := s.builder
 
 	var tx *sql.Tx
 	var stmt *sql.Stmt
 	if s.useTxStmt {
 		var err error
-		if tx, err = s.db.Begin(); err != nil {
+		if tx, err = s.db.BeginTx(ctx, nil); err != nil {
 			return nil, err
 		}
 		sqlStr, _, err := insertBuilder.ToSql()
@@ -266,7 +272,7 @@ func (s *sqlInsertProcessor) ProcessBatch(ctx context.Context, batch service.Mes
 
 		if tx == nil {
 			insertBuilder = insertBuilder.Values(args...)
-		} else if _, err := stmt.Exec(args...); err != nil {
+		} else if _, err := stmt.ExecContext(ctx, args...); err != nil {
 			return nil, err
 		}
 	}
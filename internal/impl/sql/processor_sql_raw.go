@@ -52,6 +52,9 @@ If the query fails to execute then the message will remain unchanged and the err
 			Description("Whether the query result should be discarded. When set to `true` the message contents will remain unchanged, which is useful in cases where you are executing inserts, updates, etc.").
 			Default(false))
 
+	for _, f := range queryFields() {
+		spec = spec.Field(f)
+	}
 	for _, f := range connFields() {
 		spec = spec.Field(f)
 	}
@@ -113,6 +116,7 @@ type sqlRawProcessor struct {
 
 	argsMapping   *bloblang.Executor
 	argsConverter argsConverter
+	connSettings  *connSettings
 
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
@@ -189,6 +193,7 @@ func newSQLRawProcessor(
 		onlyExec:      onlyExec,
 		argsMapping:   argsMapping,
 		argsConverter: argsConverter,
+		connSettings:  connSettings,
 	}
 
 	var err error
@@ -255,31 +260,40 @@ func (s *sqlRawProcessor) ProcessBatch(ctx context.Context, batch service.Messag
 			}
 		}
 
-		if s.onlyExec {
-			if _, err := s.db.ExecContext(ctx, queryStr, args...); err != nil {
-				s.logger.Debugf("Failed to run query: %v", err)
-				msg.SetError(err)
-				continue
-			}
-		} else {
-			rows, err := s.db.QueryContext(ctx, queryStr, args...)
-			if err != nil {
-				s.logger.Debugf("Failed to run query: %v", err)
-				msg.SetError(err)
-				continue
-			}
-
-			if jArray, err := sqlRowsToArray(rows); err != nil {
-				s.logger.Debugf("Failed to convert rows: %v", err)
-				msg.SetError(err)
-			} else {
-				msg.SetStructuredMut(jArray)
-			}
+		if err := s.query(ctx, msg, queryStr, args); err != nil {
+			msg.SetError(err)
 		}
 	}
 	return []service.MessageBatch{batch}, nil
 }
 
+func (s *sqlRawProcessor) query(ctx context.Context, msg *service.Message, queryStr string, args []any) error {
+	ctx, done := s.connSettings.queryContext(ctx)
+	defer done()
+
+	if s.onlyExec {
+		if _, err := s.db.ExecContext(ctx, queryStr, args...); err != nil {
+			s.logger.Debugf("Failed to run query: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		s.logger.Debugf("Failed to run query: %v", err)
+		return err
+	}
+
+	jArray, err := sqlRowsToArray(rows)
+	if err != nil {
+		s.logger.Debugf("Failed to convert rows: %v", err)
+		return err
+	}
+	msg.SetStructuredMut(jArray)
+	return nil
+}
+
 func (s *sqlRawProcessor) Close(ctx context.Context) error {
 	s.shutSig.TriggerHardStop()
 	select {
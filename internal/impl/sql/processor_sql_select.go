@@ -64,6 +64,9 @@ If the query fails to execute then the message will remain unchanged and the err
 			Optional().
 			Advanced())
 
+	for _, f := range queryFields() {
+		spec = spec.Field(f)
+	}
 	for _, f := range connFields() {
 		spec = spec.Field(f)
 	}
@@ -111,8 +114,9 @@ type sqlSelectProcessor struct {
 	builder squirrel.SelectBuilder
 	dbMut   sync.RWMutex
 
-	where       string
-	argsMapping *bloblang.Executor
+	where        string
+	argsMapping  *bloblang.Executor
+	connSettings *connSettings
 
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
@@ -180,15 +184,14 @@ func NewSQLSelectProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Re
 		s.builder = s.builder.Suffix(suffixStr)
 	}
 
-	connSettings, err := connSettingsFromParsed(conf, mgr)
-	if err != nil {
+	if s.connSettings, err = connSettingsFromParsed(conf, mgr); err != nil {
 		return nil, err
 	}
 
 	if s.db, err = sqlOpenWithReworks(mgr.Logger(), driverStr, dsnStr); err != nil {
 		return nil, err
 	}
-	connSettings.apply(context.Background(), s.db, s.logger)
+	s.connSettings.apply(context.Background(), s.db, s.logger)
 
 	go func() {
 		<-s.shutSig.HardStopChan()
@@ -242,23 +245,32 @@ func (s *sqlSelectProcessor) ProcessBatch(ctx context.Context, batch service.Mes
 			queryBuilder = queryBuilder.Where(s.where, args...)
 		}
 
-		rows, err := queryBuilder.RunWith(s.db).QueryContext(ctx)
-		if err != nil {
-			s.logger.Debugf("Failed to run query: %v", err)
-			msg.SetError(err)
-			continue
-		}
-
-		if jArray, err := sqlRowsToArray(rows); err != nil {
-			s.logger.Debugf("Failed to convert rows: %v", err)
+		if err := s.query(ctx, msg, queryBuilder); err != nil {
 			msg.SetError(err)
-		} else {
-			msg.SetStructuredMut(jArray)
 		}
 	}
 	return []service.MessageBatch{batch}, nil
 }
 
+func (s *sqlSelectProcessor) query(ctx context.Context, msg *service.Message, queryBuilder squirrel.SelectBuilder) error {
+	ctx, done := s.connSettings.queryContext(ctx)
+	defer done()
+
+	rows, err := queryBuilder.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		s.logger.Debugf("Failed to run query: %v", err)
+		return err
+	}
+
+	jArray, err := sqlRowsToArray(rows)
+	if err != nil {
+		s.logger.Debugf("Failed to convert rows: %v", err)
+		return err
+	}
+	msg.SetStructuredMut(jArray)
+	return nil
+}
+
 func (s *sqlSelectProcessor) Close(ctx context.Context) error {
 	s.shutSig.TriggerHardStop()
 	select {
@@ -34,13 +34,16 @@ import (
 	_ "github.com/redpanda-data/connect/v4/public/components/cypher"
 	_ "github.com/redpanda-data/connect/v4/public/components/dgraph"
 	_ "github.com/redpanda-data/connect/v4/public/components/discord"
+	_ "github.com/redpanda-data/connect/v4/public/components/docker"
 	_ "github.com/redpanda-data/connect/v4/public/components/elasticsearch"
+	_ "github.com/redpanda-data/connect/v4/public/components/feed"
 	_ "github.com/redpanda-data/connect/v4/public/components/gcp"
 	_ "github.com/redpanda-data/connect/v4/public/components/hdfs"
 	_ "github.com/redpanda-data/connect/v4/public/components/influxdb"
 	_ "github.com/redpanda-data/connect/v4/public/components/io"
 	_ "github.com/redpanda-data/connect/v4/public/components/jaeger"
 	_ "github.com/redpanda-data/connect/v4/public/components/javascript"
+	_ "github.com/redpanda-data/connect/v4/public/components/journald"
 	_ "github.com/redpanda-data/connect/v4/public/components/kafka"
 	_ "github.com/redpanda-data/connect/v4/public/components/maxmind"
 	_ "github.com/redpanda-data/connect/v4/public/components/memcached"